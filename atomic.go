@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic replaces the file at path with data by writing a temporary file in the same
+// directory, syncing it to disk and renaming it over the original, so readers only ever see
+// the old or the new contents, the mode and the owner of an existing file are preserved and
+// symlinks are followed so that the file they point to is replaced instead of the link
+func writeFileAtomic(path string, data []byte, defaultMode os.FileMode) error {
+	path, err := resolveSymlinks(path)
+
+	if err != nil {
+		return err
+	}
+
+	mode := defaultMode
+	info, statErr := os.Stat(path)
+
+	if statErr == nil {
+		mode = info.Mode().Perm()
+	}
+
+	dir, base := filepath.Split(path)
+
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := ioutil.TempFile(dir, "."+base+".tmp")
+
+	if err != nil {
+		return err
+	}
+
+	tmpName := tmp.Name()
+
+	// the temporary file is removed unless it has been renamed over the original
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmpName, mode); err != nil {
+		return err
+	}
+
+	if statErr == nil {
+		if uid, gid, ok := fileOwner(info); ok && (uid != os.Getuid() || gid != os.Getgid()) {
+			if err := os.Chown(tmpName, uid, gid); err != nil {
+				return fmt.Errorf("could not preserve the owner of %s: %s", path, err)
+			}
+		}
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		return err
+	}
+
+	syncDir(dir)
+
+	return nil
+}
+
+// maxSymlinks is the number of links resolveSymlinks follows before giving up on a loop
+const maxSymlinks = 255
+
+// resolveSymlinks returns the file the path points to, following the symlinks like opening
+// the path would, a dangling symlink resolves to the missing file it points to
+func resolveSymlinks(path string) (string, error) {
+	for i := 0; i < maxSymlinks; i++ {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			return resolved, nil
+		}
+
+		info, err := os.Lstat(path)
+
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			return path, nil
+		}
+
+		target, err := os.Readlink(path)
+
+		if err != nil {
+			return "", err
+		}
+
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+
+		path = target
+	}
+
+	return "", fmt.Errorf("too many levels of symbolic links in %s", path)
+}
+
+// syncDir flushes the directory entry of a renamed file to disk, errors are ignored
+// since not every platform supports syncing directories
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
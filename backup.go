@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+
+	"github.com/adnanh/hookman/document"
+	"github.com/codegangsta/cli"
+)
+
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d.bak", path, n)
+}
+
+// rotateBackups shifts the existing backups of the file by one, dropping the oldest one,
+// and stores the current contents of the file as backup number 1
+func rotateBackups(path string, count int) error {
+	if count <= 0 {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	os.Remove(backupPath(path, count))
+
+	for n := count - 1; n >= 1; n-- {
+		if err := os.Rename(backupPath(path, n), backupPath(path, n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return writeFileAtomic(backupPath(path, 1), data, 0600)
+}
+
+// writeHooksFile backs up the current hooks file, atomically replaces it with data and
+// records the change in the history journal
+func writeHooksFile(data []byte) error {
+	return writeHooksFileUndoing(data, nil)
+}
+
+// writeHooksFileUndoing is writeHooksFile for changes that undo the given history records
+func writeHooksFileUndoing(data []byte, undoes []int) error {
+	previous, err := ioutil.ReadFile(hooksFile)
+
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not read hooks file: %s\n", err)
+	}
+
+	if err := rotateBackups(hooksFile, backupCount); err != nil {
+		return fmt.Errorf("could not back up hooks file: %s\n", err)
+	}
+
+	if err := writeFileAtomic(hooksFile, data, 0644); err != nil {
+		return fmt.Errorf("could not create hooks file: %s\n", err)
+	}
+
+	if recordHistory {
+		if err := appendHistory(previous, data, undoes); err != nil {
+			log.Printf("warning: could not record the change in the history: %s\n", err)
+		}
+	}
+
+	log.Println("ok")
+
+	return nil
+}
+
+// backupListing is a backup in the restore --list output
+type backupListing struct {
+	Backup int       `json:"backup"`
+	File   string    `json:"file"`
+	Time   time.Time `json:"time"`
+}
+
+func restoreHooksFile(c *cli.Context) {
+	hooksFile = singleHooksFile(c, "restore")
+
+	format, err := detectFormat(c.GlobalString("format"), hooksFile)
+
+	if err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	hooksFormat = format
+
+	if c.Bool("list") {
+		format := outputFormat(c)
+		backups := make([]backupListing, 0)
+
+		for n := 1; n <= backupCount; n++ {
+			if info, err := os.Stat(backupPath(hooksFile, n)); err == nil {
+				backups = append(backups, backupListing{Backup: n, File: backupPath(hooksFile, n), Time: info.ModTime()})
+
+				if format == outputText {
+					log.Printf("  %d: %s (%s)\n", n, backupPath(hooksFile, n), info.ModTime().Format("2006-01-02 15:04:05"))
+				}
+			}
+		}
+
+		if format != outputText {
+			writeOutput(format, backups)
+		}
+
+		return
+	}
+
+	lockHooks(c)
+
+	n := c.Int("backup")
+	data, err := ioutil.ReadFile(backupPath(hooksFile, n))
+
+	if err != nil {
+		log.Fatalf("error: could not read backup %d: %s\n", n, err)
+	}
+
+	// refuse to restore a backup that webhook would not be able to load
+	converted, err := codecs[format].toJSON(parseableHooksData(data, format))
+
+	if err == nil {
+		_, err = document.UnmarshalArray(converted)
+	}
+
+	if err != nil {
+		log.Fatalf("error: backup %d is not a valid hooks file: %s\n", n, err)
+	}
+
+	log.Printf("restoring %s from %s\n", hooksFile, backupPath(hooksFile, n))
+
+	if err := writeHooksFile(data); err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/adnanh/hookman/json5"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatJSON  = "json"
+	formatJSON5 = "json5"
+	formatYAML  = "yaml"
+)
+
+// hooksCodec reads and writes hooks documents in a single format, documents are
+// converted to and from JSON so the hook structs are always decoded the same way
+type hooksCodec struct {
+	toJSON   func([]byte) ([]byte, error)
+	fromJSON func([]byte) ([]byte, error)
+}
+
+var codecs = map[string]hooksCodec{
+	formatJSON: {
+		toJSON: func(data []byte) ([]byte, error) {
+			return data, nil
+		},
+		fromJSON: func(data []byte) ([]byte, error) {
+			return encodeJSON(json.RawMessage(data), "", "  ")
+		},
+	},
+	formatJSON5: {
+		toJSON: json5.ToJSON,
+		fromJSON: func(data []byte) ([]byte, error) {
+			// every JSON document is a valid JSON5 document
+			return encodeJSON(json.RawMessage(data), "", "  ")
+		},
+	},
+	formatYAML: {
+		toJSON:   yamlToJSON,
+		fromJSON: jsonToYAML,
+	},
+}
+
+func (codec hooksCodec) marshal(v interface{}) ([]byte, error) {
+	data, err := encodeJSON(v, "", "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return codec.fromJSON(data)
+}
+
+// detectFormat returns the requested format, or the one matching the extension of the
+// given path, JSON is used for unknown extensions
+func detectFormat(requested, path string) (string, error) {
+	if requested != "" {
+		requested = strings.ToLower(requested)
+
+		if requested == "yml" {
+			requested = formatYAML
+		}
+
+		if _, ok := codecs[requested]; !ok {
+			return "", fmt.Errorf("unsupported hooks file format %s", requested)
+		}
+
+		return requested, nil
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json5":
+		return formatJSON5, nil
+	case ".yaml", ".yml":
+		return formatYAML, nil
+	default:
+		return formatJSON, nil
+	}
+}
+
+// yamlToJSON converts a YAML document to JSON keeping the order of the mapping keys
+func yamlToJSON(data []byte) ([]byte, error) {
+	var document yaml.Node
+
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	if len(document.Content) == 0 {
+		return []byte("[]"), nil
+	}
+
+	var out bytes.Buffer
+
+	if err := writeYAMLNodeAsJSON(&out, document.Content[0]); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+func writeYAMLNodeAsJSON(out *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return writeYAMLNodeAsJSON(out, node.Alias)
+	case yaml.MappingNode:
+		out.WriteByte('{')
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+
+			key, _ := json.Marshal(node.Content[i].Value)
+			out.Write(key)
+			out.WriteByte(':')
+
+			if err := writeYAMLNodeAsJSON(out, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+
+		out.WriteByte('}')
+	case yaml.SequenceNode:
+		out.WriteByte('[')
+
+		for i, item := range node.Content {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+
+			if err := writeYAMLNodeAsJSON(out, item); err != nil {
+				return err
+			}
+		}
+
+		out.WriteByte(']')
+	case yaml.ScalarNode:
+		var value interface{}
+
+		switch node.ShortTag() {
+		case "!!str", "!!binary", "!!timestamp":
+			value = node.Value
+		case "!!int", "!!float", "!!bool", "!!null":
+			if err := node.Decode(&value); err != nil {
+				return err
+			}
+		default:
+			value = node.Value
+		}
+
+		if f, ok := value.(float64); ok {
+			out.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+			return nil
+		}
+
+		encoded, err := json.Marshal(value)
+
+		if err != nil {
+			return fmt.Errorf("line %d: %s", node.Line, err)
+		}
+
+		out.Write(encoded)
+	default:
+		return fmt.Errorf("line %d: unsupported YAML node", node.Line)
+	}
+
+	return nil
+}
+
+// jsonToYAML converts a JSON document to block style YAML keeping the order of the object keys
+func jsonToYAML(data []byte) ([]byte, error) {
+	var document yaml.Node
+
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	clearYAMLStyle(&document)
+
+	var out bytes.Buffer
+
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(&document); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+func clearYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
+	}
+}
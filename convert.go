@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/adnanh/hookman/document"
+	"github.com/adnanh/webhook/hook"
+	"github.com/codegangsta/cli"
+)
+
+// readHooksDocument returns the given hooks file decoded into hooks, into ordered hook
+// documents and into a generic JSON value with numbers kept as json.Number
+func readHooksDocument(path, format string) (hook.Hooks, []*document.Object, interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if data, err = codecs[format].toJSON(data); err != nil {
+		return nil, nil, nil, err
+	}
+
+	var h hook.Hooks
+
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, nil, nil, err
+	}
+
+	documents, err := document.UnmarshalArray(data)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	generic, err := decodeGeneric(data)
+
+	return h, documents, generic, err
+}
+
+func decodeGeneric(data []byte) (interface{}, error) {
+	var document interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	return document, decoder.Decode(&document)
+}
+
+// lostPaths returns the paths of the values from the source that are missing or
+// different in the result, a key missing from the result is reported whatever its value
+func lostPaths(source, result interface{}, path string) []string {
+	switch s := source.(type) {
+	case map[string]interface{}:
+		r, ok := result.(map[string]interface{})
+
+		if !ok {
+			return []string{path}
+		}
+
+		var paths []string
+
+		for key, value := range s {
+			keyPath := path + "." + key
+
+			if path == "" {
+				keyPath = key
+			}
+
+			if other, ok := r[key]; ok {
+				paths = append(paths, lostPaths(value, other, keyPath)...)
+			} else {
+				paths = append(paths, keyPath)
+			}
+		}
+
+		sort.Strings(paths)
+
+		return paths
+	case []interface{}:
+		r, ok := result.([]interface{})
+
+		if !ok || len(r) != len(s) {
+			return []string{path}
+		}
+
+		var paths []string
+
+		for idx := range s {
+			paths = append(paths, lostPaths(s[idx], r[idx], fmt.Sprintf("%s[%d]", path, idx))...)
+		}
+
+		return paths
+	}
+
+	if !reflect.DeepEqual(source, result) {
+		return []string{path}
+	}
+
+	return nil
+}
+
+func formatFlag(c *cli.Context, name, path string) string {
+	format, err := detectFormat(c.String(name), path)
+
+	if err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	return format
+}
+
+func convertHooksFile(c *cli.Context) {
+	if len(c.Args()) != 2 {
+		log.Fatalln("error: you must supply the input and the output hooks file")
+	}
+
+	input, output := c.Args()[0], c.Args()[1]
+	inputFormat, outputFormat := formatFlag(c, "from", input), formatFlag(c, "to", output)
+
+	inputHooks, inputDocuments, inputGeneric, err := readHooksDocument(input, inputFormat)
+
+	if err != nil {
+		log.Fatalf("error: could not load hooks from file %s: %s\n", input, err)
+	}
+
+	if c.Bool("check") {
+		checkConvertedHooksFile(inputHooks, output, outputFormat)
+		return
+	}
+
+	if _, err := os.Stat(output); err == nil && !c.Bool("force") {
+		log.Fatalf("error: %s already exists, use --force to overwrite it\n", output)
+	}
+
+	data, err := encodeJSON(inputDocuments, "", "")
+
+	if err != nil {
+		log.Fatalf("error: could not format hooks: %s\n", err)
+	}
+
+	convertedDocument, err := decodeGeneric(data)
+
+	if err != nil {
+		log.Fatalf("error: could not format hooks: %s\n", err)
+	}
+
+	if paths := lostPaths(inputGeneric, convertedDocument, ""); len(paths) > 0 {
+		log.Fatalf("error: refusing to convert, the following fields would be lost:\n   %s\n", strings.Join(paths, "\n   "))
+	}
+
+	if data, err = codecs[outputFormat].fromJSON(data); err != nil {
+		log.Fatalf("error: could not format hooks: %s\n", err)
+	}
+
+	if err := writeFileAtomic(output, data, 0644); err != nil {
+		log.Fatalf("error: could not create hooks file: %s\n", err)
+	}
+
+	log.Printf("converted %d hook(s) from %s (%s) to %s (%s)\n", len(inputHooks), input, inputFormat, output, outputFormat)
+}
+
+func checkConvertedHooksFile(inputHooks hook.Hooks, output, outputFormat string) {
+	outputHooks, _, _, err := readHooksDocument(output, outputFormat)
+
+	if err != nil {
+		log.Fatalf("error: could not load hooks from file %s: %s\n", output, err)
+	}
+
+	if len(inputHooks) != len(outputHooks) {
+		log.Fatalf("error: files differ, %d hook(s) in the input and %d hook(s) in the output\n", len(inputHooks), len(outputHooks))
+	}
+
+	differ := false
+
+	for idx := range inputHooks {
+		if !reflect.DeepEqual(inputHooks[idx], outputHooks[idx]) {
+			differ = true
+			log.Printf(" ! hook %s at position %d differs\n", inputHooks[idx].ID, idx)
+		}
+	}
+
+	if differ {
+		log.Fatalln("error: files are not semantically identical")
+	}
+
+	log.Println("ok")
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLostPaths(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		result string
+		want   []string
+	}{
+		{"same", `[{"id":"a","http-methods":[]}]`, `[{"id":"a","http-methods":[]}]`, nil},
+		{"missing value", `[{"id":"a","execute-command":"x"}]`, `[{"id":"a"}]`, []string{"[0].execute-command"}},
+		{"missing empty array", `[{"id":"a","http-methods":[]}]`, `[{"id":"a"}]`, []string{"[0].http-methods"}},
+		{"missing empty string", `[{"id":"a","x-note":""}]`, `[{"id":"a"}]`, []string{"[0].x-note"}},
+		{"missing false", `[{"id":"a","include-command-output-in-response":false}]`, `[{"id":"a"}]`, []string{"[0].include-command-output-in-response"}},
+		{"missing null", `[{"id":"a","trigger-rule":null}]`, `[{"id":"a"}]`, []string{"[0].trigger-rule"}},
+		{"changed value", `[{"id":"a","x":{"y":1}}]`, `[{"id":"a","x":{"y":2}}]`, []string{"[0].x.y"}},
+		{"changed length", `[{"id":"a"},{"id":"b"}]`, `[{"id":"a"}]`, []string{""}},
+	}
+
+	for _, test := range tests {
+		source, err := decodeGeneric([]byte(test.source))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := decodeGeneric([]byte(test.result))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := lostPaths(source, result, ""); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/codegangsta/cli"
+)
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "'\\''", -1) + "'"
+}
+
+func printCurlCommand(c *cli.Context) {
+	if err := loadHooks(c); err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	h, err := findOneHookByID(c)
+
+	if err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	solution := satisfyHook(c, h)
+
+	target := strings.TrimSuffix(c.String("base-url"), "/") + "/" + strings.Trim(c.String("prefix"), "/") + "/" + url.PathEscape(h.ID)
+
+	if len(solution.Query) > 0 {
+		query := url.Values{}
+
+		for name, value := range solution.Query {
+			query.Set(name, value)
+		}
+
+		target += "?" + query.Encode()
+	}
+
+	lines := []string{fmt.Sprintf("curl -X POST %s", shellQuote(target)), shellQuote("Content-Type: application/json")}
+
+	var headerNames []string
+
+	for name := range solution.Header {
+		headerNames = append(headerNames, name)
+	}
+
+	sort.Strings(headerNames)
+
+	for _, name := range headerNames {
+		lines = append(lines, shellQuote(fmt.Sprintf("%s: %s", name, solution.Header[name])))
+	}
+
+	for i := 1; i < len(lines); i++ {
+		lines[i] = "  -H " + lines[i]
+	}
+
+	lines = append(lines, "  -d "+shellQuote(string(solution.Body)))
+
+	fmt.Println(strings.Join(lines, " \\\n"))
+}
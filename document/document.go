@@ -0,0 +1,214 @@
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Object is a JSON object that keeps the order of its keys and the raw encoding of its values
+type Object struct {
+	Keys   []string
+	Values map[string]json.RawMessage
+}
+
+// New returns an empty object
+func New() *Object {
+	return &Object{Values: make(map[string]json.RawMessage)}
+}
+
+// FromValue returns the object holding the JSON encoding of the given value
+func FromValue(v interface{}) (*Object, error) {
+	data, err := json.Marshal(v)
+
+	if err != nil {
+		return nil, err
+	}
+
+	o := New()
+
+	return o, json.Unmarshal(data, o)
+}
+
+// UnmarshalArray decodes a JSON array of objects
+func UnmarshalArray(data []byte) ([]*Object, error) {
+	var objects []*Object
+
+	if err := json.Unmarshal(data, &objects); err != nil {
+		return nil, err
+	}
+
+	for idx, o := range objects {
+		if o == nil {
+			return nil, fmt.Errorf("element %d is not an object", idx)
+		}
+	}
+
+	return objects, nil
+}
+
+// Get returns the raw value stored under the given key
+func (o *Object) Get(key string) (json.RawMessage, bool) {
+	value, ok := o.Values[key]
+
+	return value, ok
+}
+
+// Set stores the raw value under the given key, new keys are appended at the end
+func (o *Object) Set(key string, value json.RawMessage) {
+	if _, ok := o.Values[key]; !ok {
+		o.Keys = append(o.Keys, key)
+	}
+
+	o.Values[key] = value
+}
+
+// Delete removes the given key
+func (o *Object) Delete(key string) {
+	if _, ok := o.Values[key]; !ok {
+		return
+	}
+
+	delete(o.Values, key)
+
+	for idx, k := range o.Keys {
+		if k == key {
+			o.Keys = append(o.Keys[:idx], o.Keys[idx+1:]...)
+			break
+		}
+	}
+}
+
+// Decode decodes the object into the given value
+func (o *Object) Decode(v interface{}) error {
+	data, err := json.Marshal(o)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, duplicate keys keep the position of the
+// first occurrence and the value of the last one, like encoding/json does
+func (o *Object) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	token, err := decoder.Token()
+
+	if err != nil {
+		return err
+	}
+
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("expected JSON object")
+	}
+
+	o.Keys, o.Values = nil, make(map[string]json.RawMessage)
+
+	for decoder.More() {
+		token, err := decoder.Token()
+
+		if err != nil {
+			return err
+		}
+
+		var value json.RawMessage
+
+		if err := decoder.Decode(&value); err != nil {
+			return err
+		}
+
+		o.Set(token.(string), value)
+	}
+
+	_, err = decoder.Token()
+
+	return err
+}
+
+// MarshalJSON implements json.Marshaler
+func (o *Object) MarshalJSON() ([]byte, error) {
+	var out bytes.Buffer
+
+	out.WriteByte('{')
+
+	for idx, key := range o.Keys {
+		if idx > 0 {
+			out.WriteByte(',')
+		}
+
+		encodedKey, err := json.Marshal(key)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out.Write(encodedKey)
+		out.WriteByte(':')
+
+		value := o.Values[key]
+
+		if len(value) == 0 {
+			value = json.RawMessage("null")
+		}
+
+		out.Write(value)
+	}
+
+	out.WriteByte('}')
+
+	return out.Bytes(), nil
+}
+
+// MergePatch applies the JSON merge patch (RFC 7386) to the object, patched keys keep their
+// position and new keys are appended at the end
+func (o *Object) MergePatch(patch *Object) error {
+	for _, key := range patch.Keys {
+		value := patch.Values[key]
+
+		switch {
+		case bytes.Equal(bytes.TrimSpace(value), []byte("null")):
+			o.Delete(key)
+
+		case isObject(value):
+			target := New()
+
+			if current, ok := o.Get(key); ok && isObject(current) {
+				if err := json.Unmarshal(current, target); err != nil {
+					return err
+				}
+			}
+
+			nested := New()
+
+			if err := json.Unmarshal(value, nested); err != nil {
+				return err
+			}
+
+			if err := target.MergePatch(nested); err != nil {
+				return err
+			}
+
+			encoded, err := json.Marshal(target)
+
+			if err != nil {
+				return err
+			}
+
+			o.Set(key, encoded)
+
+		default:
+			o.Set(key, value)
+		}
+	}
+
+	return nil
+}
+
+func isObject(value json.RawMessage) bool {
+	value = bytes.TrimSpace(value)
+
+	return len(value) > 0 && value[0] == '{'
+}
@@ -0,0 +1,110 @@
+package document
+
+import (
+	"fmt"
+)
+
+// Span is a range of bytes [Start, End) in a document
+type Span struct {
+	Start int
+	End   int
+}
+
+// ArrayLayout holds the positions of the brackets and the elements of a top level JSON array
+type ArrayLayout struct {
+	Open     int
+	Close    int
+	Elements []Span
+}
+
+func isJSONWhitespace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
+
+func skipJSONWhitespace(data []byte, pos int) int {
+	for pos < len(data) && isJSONWhitespace(data[pos]) {
+		pos++
+	}
+
+	return pos
+}
+
+// skipJSONValue returns the position right after the JSON value starting at pos
+func skipJSONValue(data []byte, pos int) (int, error) {
+	depth := 0
+	inString := false
+
+	for i := pos; i < len(data); i++ {
+		ch := data[i]
+
+		switch {
+		case inString && ch == '\\':
+			i++
+		case inString:
+			inString = ch != '"'
+		case ch == '"':
+			inString = true
+		case ch == '{' || ch == '[':
+			depth++
+		case ch == '}' || ch == ']':
+			depth--
+
+			if depth < 0 {
+				return i, nil
+			}
+		case ch == ',' || isJSONWhitespace(ch):
+			if depth == 0 {
+				return i, nil
+			}
+		}
+
+		if depth == 0 && !inString && (ch == '}' || ch == ']' || ch == '"') {
+			return i + 1, nil
+		}
+	}
+
+	if depth == 0 && !inString {
+		return len(data), nil
+	}
+
+	return 0, fmt.Errorf("unexpected end of JSON input")
+}
+
+// Layout returns the layout of the top level JSON array in data
+func Layout(data []byte) (*ArrayLayout, error) {
+	pos := skipJSONWhitespace(data, 0)
+
+	if pos >= len(data) || data[pos] != '[' {
+		return nil, fmt.Errorf("expected JSON array")
+	}
+
+	layout := &ArrayLayout{Open: pos}
+
+	pos = skipJSONWhitespace(data, pos+1)
+
+	for pos < len(data) && data[pos] != ']' {
+		end, err := skipJSONValue(data, pos)
+
+		if err != nil {
+			return nil, err
+		}
+
+		layout.Elements = append(layout.Elements, Span{Start: pos, End: end})
+
+		pos = skipJSONWhitespace(data, end)
+
+		if pos < len(data) && data[pos] == ',' {
+			pos = skipJSONWhitespace(data, pos+1)
+		} else if pos >= len(data) || data[pos] != ']' {
+			return nil, fmt.Errorf("expected , or ] at offset %d", pos)
+		}
+	}
+
+	if pos >= len(data) {
+		return nil, fmt.Errorf("unexpected end of JSON input")
+	}
+
+	layout.Close = pos
+
+	return layout, nil
+}
@@ -0,0 +1,67 @@
+package document
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		elements []string
+	}{
+		{"empty", `[]`, nil},
+		{"empty with whitespace", " \n[ \n ]\n", nil},
+		{"objects", `[{"a":1},{"b":2}]`, []string{`{"a":1}`, `{"b":2}`}},
+		{"indented", "[\n  {\n    \"a\": 1\n  },\n  {\n    \"b\": [2, 3]\n  }\n]\n", []string{"{\n    \"a\": 1\n  }", "{\n    \"b\": [2, 3]\n  }"}},
+		{"crlf", "[\r\n\t{\"a\": 1},\r\n\t{\"b\": 2}\r\n]\r\n", []string{`{"a": 1}`, `{"b": 2}`}},
+		{"brackets in strings", `[{"a":"]}[{,"},{"b":"\"]"}]`, []string{`{"a":"]}[{,"}`, `{"b":"\"]"}`}},
+		{"escaped backslash", `[{"a":"\\"},{"b":1}]`, []string{`{"a":"\\"}`, `{"b":1}`}},
+		{"scalars", `[1, "two", true, null]`, []string{`1`, `"two"`, `true`, `null`}},
+		{"space before comma", `[{"a":1} , {"b":2} ]`, []string{`{"a":1}`, `{"b":2}`}},
+	}
+
+	for _, test := range tests {
+		layout, err := Layout([]byte(test.data))
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+
+		if first, last := strings.Index(test.data, "["), strings.LastIndex(test.data, "]"); layout.Open != first || layout.Close != last {
+			t.Errorf("%s: got brackets at %d and %d, want %d and %d", test.name, layout.Open, layout.Close, first, last)
+		}
+
+		var elements []string
+
+		for _, span := range layout.Elements {
+			elements = append(elements, test.data[span.Start:span.End])
+		}
+
+		if !reflect.DeepEqual(elements, test.elements) {
+			t.Errorf("%s: got elements %q, want %q", test.name, elements, test.elements)
+		}
+	}
+}
+
+func TestLayoutErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ``},
+		{"object", `{"a": 1}`},
+		{"unterminated array", `[{"a": 1}`},
+		{"unterminated string", `[{"a": "1}]`},
+		{"missing comma", `[{"a": 1} {"b": 2}]`},
+	}
+
+	for _, test := range tests {
+		if _, err := Layout([]byte(test.data)); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
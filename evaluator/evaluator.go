@@ -0,0 +1,157 @@
+package evaluator
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/adnanh/hookman/fixture"
+	"github.com/adnanh/webhook/hook"
+)
+
+const sha1SignaturePrefix = "sha1="
+
+// ErrRedactedParameter is returned when a rule depends on a header that was redacted
+// while recording the request
+var ErrRedactedParameter = errors.New("rule depends on a redacted header")
+
+// Evaluate returns true if the given request satisfies the rule the same way webhook would
+// evaluate it, nil rule is always satisfied
+func Evaluate(r *hook.Rules, req *fixture.Request) (bool, error) {
+	if r == nil {
+		return true, nil
+	}
+
+	switch {
+	case r.And != nil:
+		for _, rule := range *r.And {
+			ok, err := Evaluate(&rule, req)
+
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+
+		return true, nil
+	case r.Or != nil:
+		for _, rule := range *r.Or {
+			ok, err := Evaluate(&rule, req)
+
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+
+		return false, nil
+	case r.Not != nil:
+		ok, err := Evaluate((*hook.Rules)(r.Not), req)
+
+		return !ok, err
+	case r.Match != nil:
+		return evaluateMatch(r.Match, req)
+	}
+
+	return false, nil
+}
+
+func evaluateMatch(r *hook.MatchRule, req *fixture.Request) (bool, error) {
+	value, ok := ExtractParameter(r.Parameter, req)
+
+	if !ok {
+		return false, nil
+	}
+
+	if isRedacted(r.Parameter, req) {
+		return false, ErrRedactedParameter
+	}
+
+	switch r.Type {
+	case hook.MatchValue:
+		return value == r.Value, nil
+	case hook.MatchRegex:
+		// webhook treats a regex that does not compile as a non-match
+		ok, _ := regexp.MatchString(r.Regex, value)
+
+		return ok, nil
+	case hook.MatchHashSHA1:
+		return hmac.Equal([]byte(strings.TrimPrefix(value, sha1SignaturePrefix)), []byte(Signature(req.RawBody, r.Secret))), nil
+	}
+
+	return false, fmt.Errorf("invalid match rule type %s", r.Type)
+}
+
+// Signature returns the hex encoded HMAC SHA1 signature of the payload
+func Signature(payload []byte, secret string) string {
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ExtractParameter returns the value of the given argument from the request, payload
+// arguments can address nested objects and arrays using dots, like commits.0.id
+func ExtractParameter(arg hook.Argument, req *fixture.Request) (string, bool) {
+	switch arg.Source {
+	case hook.SourceString:
+		return arg.Name, true
+	case hook.SourceHeader:
+		return extractParameter(textproto.CanonicalMIMEHeaderKey(arg.Name), req.Headers)
+	case hook.SourceQuery:
+		return extractParameter(arg.Name, req.Query)
+	case hook.SourcePayload:
+		return extractParameter(arg.Name, req.Payload)
+	}
+
+	return "", false
+}
+
+func extractParameter(name string, params interface{}) (string, bool) {
+	for _, key := range strings.Split(name, ".") {
+		switch p := params.(type) {
+		case map[string]interface{}:
+			value, ok := p[key]
+
+			if !ok {
+				return "", false
+			}
+
+			params = value
+		case []interface{}:
+			idx, err := strconv.Atoi(key)
+
+			if err != nil || idx < 0 || idx >= len(p) {
+				return "", false
+			}
+
+			params = p[idx]
+		default:
+			return "", false
+		}
+	}
+
+	if params == nil {
+		return "", false
+	}
+
+	return fmt.Sprintf("%v", params), true
+}
+
+func isRedacted(arg hook.Argument, req *fixture.Request) bool {
+	if arg.Source != hook.SourceHeader || req.Recorded == nil {
+		return false
+	}
+
+	for _, name := range req.Recorded.Redacted {
+		if textproto.CanonicalMIMEHeaderKey(name) == textproto.CanonicalMIMEHeaderKey(arg.Name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,57 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/adnanh/hookman/fixture"
+	"github.com/adnanh/webhook/hook"
+)
+
+func TestEvaluate(t *testing.T) {
+	valid := fixture.NewRequest("POST", "/hooks/a?ref=main", map[string]string{"Content-Type": "application/json", "X-Token": "s3cr3t"}, []byte(`{"commits": [{"id": "abc"}]}`))
+	malformed := fixture.NewRequest("POST", "/hooks/a?ref=main", map[string]string{"Content-Type": "application/json", "X-Token": "s3cr3t"}, []byte(`{"commits": `))
+
+	valid.Normalize()
+	malformed.Normalize()
+
+	match := func(typ, source, name, value string) *hook.Rules {
+		m := &hook.MatchRule{Type: typ, Parameter: hook.Argument{Source: source, Name: name}}
+
+		if typ == hook.MatchRegex {
+			m.Regex = value
+		} else {
+			m.Value = value
+		}
+
+		return &hook.Rules{Match: m}
+	}
+
+	tests := []struct {
+		name string
+		rule *hook.Rules
+		req  *fixture.Request
+		want bool
+	}{
+		{"no rule", nil, valid, true},
+		{"header", match(hook.MatchValue, hook.SourceHeader, "x-token", "s3cr3t"), valid, true},
+		{"nested payload", match(hook.MatchValue, hook.SourcePayload, "commits.0.id", "abc"), valid, true},
+		{"regex", match(hook.MatchRegex, hook.SourceQuery, "ref", "^ma"), valid, true},
+		{"invalid regex", match(hook.MatchRegex, hook.SourceQuery, "ref", "(main"), valid, false},
+		{"malformed payload header", match(hook.MatchValue, hook.SourceHeader, "X-Token", "s3cr3t"), malformed, true},
+		{"malformed payload query", match(hook.MatchValue, hook.SourceQuery, "ref", "main"), malformed, true},
+		{"malformed payload field", match(hook.MatchValue, hook.SourcePayload, "commits.0.id", "abc"), malformed, false},
+	}
+
+	for _, test := range tests {
+		got, err := Evaluate(test.rule, test.req)
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
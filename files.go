@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adnanh/webhook/hook"
+	"github.com/codegangsta/cli"
+)
+
+const defaultHooksFile = "hooks.json"
+
+// hooksFileExtensions are the extensions of the files picked up from hooks directories
+var hooksFileExtensions = map[string]bool{".json": true, ".json5": true, ".yaml": true, ".yml": true}
+
+// sourceFile is a hooks file the hooks were loaded from
+type sourceFile struct {
+	path     string
+	format   string
+	data     []byte
+	contents []byte
+	includes []includeDirective
+	included bool
+
+	// detached is set on the destination of move that is not one of the loaded hooks
+	// files, and on the files it includes
+	detached bool
+}
+
+// hooksFilePaths expands the --file flags, directories are replaced with the hooks files
+// they contain and globs with the files they match
+func hooksFilePaths(c *cli.Context) ([]string, error) {
+	values := c.GlobalStringSlice("file")
+
+	if len(values) == 0 {
+		values = []string{defaultHooksFile}
+	}
+
+	var paths []string
+	seen := make(map[string]bool)
+
+	add := func(path string) {
+		if path = filepath.Clean(path); !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	for _, value := range values {
+		if value == stdioFile {
+			if len(values) > 1 {
+				return nil, fmt.Errorf("--file %s cannot be combined with other hooks files", stdioFile)
+			}
+
+			return values, nil
+		}
+
+		if info, err := os.Stat(value); err == nil && info.IsDir() {
+			entries, err := ioutil.ReadDir(value)
+
+			if err != nil {
+				return nil, err
+			}
+
+			found := false
+			names := make(map[string]bool)
+
+			for _, entry := range entries {
+				names[entry.Name()] = true
+			}
+
+			for _, entry := range entries {
+				name := entry.Name()
+
+				if !entry.IsDir() && !strings.HasPrefix(name, ".") && hooksFileExtensions[strings.ToLower(filepath.Ext(name))] && !isOverlayFile(name, names) {
+					add(filepath.Join(value, name))
+					found = true
+				}
+			}
+
+			if !found {
+				return nil, fmt.Errorf("no hooks files found in directory %s", value)
+			}
+
+			continue
+		}
+
+		if strings.ContainsAny(value, "*?[") {
+			matches, err := filepath.Glob(value)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid hooks file pattern %s: %s", value, err)
+			}
+
+			found := false
+
+			for _, match := range matches {
+				if info, err := os.Stat(match); err == nil && !info.IsDir() {
+					add(match)
+					found = true
+				}
+			}
+
+			if !found {
+				return nil, fmt.Errorf("no hooks files match %s", value)
+			}
+
+			continue
+		}
+
+		add(value)
+	}
+
+	return paths, nil
+}
+
+// singleHooksFile returns the hooks file for the commands that work on exactly one file on disk
+func singleHooksFile(c *cli.Context, command string) string {
+	paths, err := hooksFilePaths(c)
+
+	if err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	if len(paths) != 1 {
+		log.Fatalf("error: %s works on a single hooks file, %d were given\n", command, len(paths))
+	}
+
+	if paths[0] == stdioFile {
+		log.Fatalf("error: %s needs a hooks file on disk and cannot be used with --file %s\n", command, stdioFile)
+	}
+
+	return paths[0]
+}
+
+// describeHooksFiles returns the loaded hooks files for messages
+func describeHooksFiles() string {
+	if len(sourceFiles) == 1 {
+		return "file: " + sourceFiles[0].path
+	}
+
+	paths := make([]string, len(sourceFiles))
+
+	for i, f := range sourceFiles {
+		paths[i] = f.path
+	}
+
+	return fmt.Sprintf("%d files: %s", len(paths), strings.Join(paths, ", "))
+}
+
+func findSourceFile(path string) *sourceFile {
+	path = filepath.Clean(path)
+
+	for _, f := range sourceFiles {
+		if f.path == path {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// hookIndex returns the position of the given loaded hook
+func hookIndex(h *hook.Hook) int {
+	for i := range hooks {
+		if &hooks[i] == h {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// loadMoveDestination loads the given hooks file that is not one of the loaded hooks files
+// as the destination of move, its hooks are written back with the moved hook but are not
+// rendered or looked up like the loaded hooks
+func loadMoveDestination(c *cli.Context, path string) *sourceFile {
+	if err := lockHooksFile(path, c.GlobalDuration("lock-timeout")); err != nil {
+		log.Fatalf("error: could not lock hooks file: %s\n", err)
+	}
+
+	var target *sourceFile
+	var err error
+
+	loadedFiles := len(sourceFiles)
+
+	if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
+		target = &sourceFile{path: filepath.Clean(path)}
+
+		if target.format, err = detectFormat(c.GlobalString("format"), path); err == nil {
+			sourceFiles = append(sourceFiles, target)
+		}
+	} else {
+		target, err = loadHooksFile(c.GlobalString("format"), path, nil)
+	}
+
+	if err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	for _, f := range sourceFiles[loadedFiles:] {
+		f.detached = true
+	}
+
+	return target
+}
+
+func moveHook(c *cli.Context) {
+	lockHooks(c)
+
+	if err := loadHooks(c); err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	terminateOnEmptyHooksFile()
+
+	to := c.String("to")
+
+	if to == "" || to == stdioFile {
+		log.Fatalln("error: you must supply the hooks file to move the hook to with --to")
+	}
+
+	h, err := findOneHookByID(c)
+
+	if err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	source := hookSources[hookIndex(h)]
+	target := findSourceFile(to)
+
+	if source.file == target {
+		log.Fatalf("error: hook is already in %s\n", target.path)
+	}
+
+	moved := *h
+
+	deleteHooks([]*hook.Hook{h})
+
+	if target == nil {
+		target = loadMoveDestination(c, to)
+	}
+
+	log.Printf(" + moving %s from %s to %s\n", moved.ID, source.file.path, target.path)
+
+	hooks = append(hooks, moved)
+	hookSources = append(hookSources, hookSource{file: target, document: source.document, index: -1})
+
+	describeChange("move", fmt.Sprintf("%s to %s", moved.ID, target.path))
+
+	if err := saveHooks(false); err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+}
@@ -0,0 +1,323 @@
+package fixture
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// FormatRaw is a raw HTTP request dump
+	FormatRaw string = "raw"
+
+	// FormatHTTP is an .http file as used by the REST client editor plugins
+	FormatHTTP = "http"
+
+	// FormatHAR is an HTTP Archive exported from a browser
+	FormatHAR = "har"
+
+	// FormatJSONLines is a file with one JSON encoded request per line
+	FormatJSONLines = "jsonl"
+)
+
+const (
+	// DefaultHooksPrefix is the URL prefix webhook serves the hooks under
+	DefaultHooksPrefix string = "hooks"
+
+	bodyEncodingBase64 = "base64"
+)
+
+// Request is a normalized webhook request fixture
+type Request struct {
+	HookID       string            `json:"hook-id,omitempty"`
+	Method       string            `json:"method,omitempty"`
+	URL          string            `json:"url,omitempty"`
+	Header       map[string]string `json:"headers,omitempty"`
+	Body         string            `json:"body,omitempty"`
+	BodyEncoding string            `json:"body-encoding,omitempty"`
+	Recorded     *Recording        `json:"recorded,omitempty"`
+
+	Headers map[string]interface{} `json:"-"`
+	Query   map[string]interface{} `json:"-"`
+	Payload map[string]interface{} `json:"-"`
+	RawBody []byte                 `json:"-"`
+
+	// Invalid holds the reason a request loaded leniently could not be normalized
+	Invalid error `json:"-"`
+
+	// PayloadError holds the reason the body could not be parsed, webhook logs such errors
+	// and evaluates the trigger rules with an empty payload
+	PayloadError error `json:"-"`
+}
+
+// Recording holds the outcome of a request captured in front of a running webhook
+type Recording struct {
+	Time     time.Time `json:"time"`
+	Status   int       `json:"status"`
+	Response string    `json:"response,omitempty"`
+	Redacted []string  `json:"redacted,omitempty"`
+}
+
+// FormatFromPath returns the fixture format matching the extension of the given path
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".har":
+		return FormatHAR
+	case ".http", ".rest":
+		return FormatHTTP
+	case ".jsonl", ".ndjson":
+		return FormatJSONLines
+	default:
+		return FormatRaw
+	}
+}
+
+// LoadFile reads the request fixtures from the given file, choosing the format by the file extension
+func LoadFile(path string) ([]*Request, error) {
+	return loadFile(path, false)
+}
+
+// LoadFileLenient is like LoadFile, but returns the requests that cannot be normalized
+// with the reason set in Invalid instead of failing, recorded traffic may contain such
+// deliveries
+func LoadFileLenient(path string) ([]*Request, error) {
+	return loadFile(path, true)
+}
+
+// Parse reads the request fixtures from data in the given format, dir is used for resolving
+// files referenced from the fixture
+func Parse(format string, data []byte, dir string) ([]*Request, error) {
+	return parse(format, data, dir, false)
+}
+
+// ParseLenient is like Parse, but returns the requests that cannot be normalized with the
+// reason set in Invalid instead of failing, requests whose body cannot be parsed keep an
+// empty payload the same way webhook handles them
+func ParseLenient(format string, data []byte, dir string) ([]*Request, error) {
+	return parse(format, data, dir, true)
+}
+
+func loadFile(path string, lenient bool) ([]*Request, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(FormatFromPath(path), data, filepath.Dir(path), lenient)
+}
+
+func parse(format string, data []byte, dir string, lenient bool) ([]*Request, error) {
+	var requests []*Request
+	var err error
+
+	switch format {
+	case FormatRaw:
+		var r *Request
+
+		if r, err = parseRaw(string(data)); err == nil {
+			requests = append(requests, r)
+		}
+	case FormatHTTP:
+		requests, err = parseHTTPFile(string(data), dir)
+	case FormatHAR:
+		requests, err = parseHAR(data)
+	case FormatJSONLines:
+		requests, err = parseJSONLines(data)
+	default:
+		return nil, fmt.Errorf("unknown fixture format %s", format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for idx, r := range requests {
+		err := r.Normalize()
+
+		switch {
+		case err == nil:
+		case !lenient:
+			return nil, fmt.Errorf("request %d: %s", idx+1, err)
+		case err != r.PayloadError:
+			r.Invalid = err
+		}
+	}
+
+	return requests, nil
+}
+
+// NewRequest returns a new request with the given method, url, headers and body
+func NewRequest(method, rawURL string, header map[string]string, body []byte) *Request {
+	r := &Request{Method: strings.ToUpper(method), URL: rawURL, Header: make(map[string]string)}
+
+	for name, value := range header {
+		r.Header[textproto.CanonicalMIMEHeaderKey(name)] = value
+	}
+
+	r.SetBody(body)
+
+	return r
+}
+
+// SetBody stores the given body, encoding it if it is not valid UTF-8
+func (r *Request) SetBody(body []byte) {
+	r.RawBody = body
+
+	if utf8.Valid(body) {
+		r.Body, r.BodyEncoding = string(body), ""
+	} else {
+		r.Body, r.BodyEncoding = base64.StdEncoding.EncodeToString(body), bodyEncodingBase64
+	}
+}
+
+// Normalize fills in the headers, query and payload parameters the same way webhook does,
+// a body that cannot be parsed leaves the payload empty and is reported in PayloadError
+func (r *Request) Normalize() error {
+	r.PayloadError = nil
+
+	if r.Method == "" {
+		r.Method = "GET"
+	}
+
+	r.Headers = make(map[string]interface{})
+
+	for name, value := range r.Header {
+		r.Headers[textproto.CanonicalMIMEHeaderKey(name)] = value
+	}
+
+	u, err := url.Parse(r.URL)
+
+	if err != nil {
+		return fmt.Errorf("invalid url %s: %s", r.URL, err)
+	}
+
+	r.Query = valuesToMap(u.Query())
+
+	if r.HookID == "" {
+		r.HookID = HookIDFromPath(u.Path, DefaultHooksPrefix)
+	}
+
+	switch r.BodyEncoding {
+	case "":
+		r.RawBody = []byte(r.Body)
+	case bodyEncodingBase64:
+		body, err := base64.StdEncoding.DecodeString(r.Body)
+
+		if err != nil {
+			return fmt.Errorf("invalid base64 body: %s", err)
+		}
+
+		r.RawBody = body
+	default:
+		return fmt.Errorf("unknown body encoding %s", r.BodyEncoding)
+	}
+
+	r.Payload = make(map[string]interface{})
+
+	if len(r.RawBody) == 0 {
+		return nil
+	}
+
+	contentType, _, _ := mime.ParseMediaType(r.Header[textproto.CanonicalMIMEHeaderKey("Content-Type")])
+
+	switch {
+	case strings.Contains(contentType, "json"):
+		decoder := json.NewDecoder(bytes.NewReader(r.RawBody))
+		decoder.UseNumber()
+
+		if err := decoder.Decode(&r.Payload); err != nil {
+			r.Payload = make(map[string]interface{})
+			r.PayloadError = fmt.Errorf("could not parse JSON payload: %s", err)
+		}
+	case strings.Contains(contentType, "form"):
+		values, err := url.ParseQuery(string(r.RawBody))
+
+		if err != nil {
+			r.PayloadError = fmt.Errorf("could not parse form payload: %s", err)
+		} else {
+			r.Payload = valuesToMap(values)
+		}
+	}
+
+	return r.PayloadError
+}
+
+// HookIDFromPath returns the hook id from the given request path, or an empty string if
+// the path does not point to a hook served under the given prefix
+func HookIDFromPath(path, prefix string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	if len(segments) != 2 || segments[0] != strings.Trim(prefix, "/") {
+		return ""
+	}
+
+	id, err := url.PathUnescape(segments[1])
+
+	if err != nil {
+		return segments[1]
+	}
+
+	return id
+}
+
+// WriteJSONLine writes the given request as a single line of JSON
+func WriteJSONLine(w io.Writer, r interface{}) error {
+	line, err := json.Marshal(r)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(line, '\n'))
+
+	return err
+}
+
+func parseJSONLines(data []byte) ([]*Request, error) {
+	var requests []*Request
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		if text == "" {
+			continue
+		}
+
+		r := &Request{}
+
+		if err := json.Unmarshal([]byte(text), r); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+
+		requests = append(requests, r)
+	}
+
+	return requests, scanner.Err()
+}
+
+func valuesToMap(values map[string][]string) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	for key, value := range values {
+		if len(value) > 0 {
+			result[key] = value[0]
+		}
+	}
+
+	return result
+}
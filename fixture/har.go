@@ -0,0 +1,88 @@
+package fixture
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/textproto"
+	"net/url"
+	"strings"
+)
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string         `json:"mimeType"`
+	Text     string         `json:"text"`
+	Encoding string         `json:"encoding"`
+	Params   []harNameValue `json:"params"`
+}
+
+type harRequest struct {
+	Method   string         `json:"method"`
+	URL      string         `json:"url"`
+	Headers  []harNameValue `json:"headers"`
+	PostData *harPostData   `json:"postData"`
+}
+
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			Request harRequest `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// parseHAR returns a request for every entry in the given HTTP Archive, HTTP/2
+// pseudo headers are skipped
+func parseHAR(data []byte) ([]*Request, error) {
+	var har harFile
+
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("could not parse HAR file: %s", err)
+	}
+
+	requests := make([]*Request, 0, len(har.Log.Entries))
+
+	for _, entry := range har.Log.Entries {
+		r := &Request{Method: entry.Request.Method, URL: entry.Request.URL, Header: make(map[string]string)}
+
+		for _, header := range entry.Request.Headers {
+			if strings.HasPrefix(header.Name, ":") {
+				continue
+			}
+
+			// webhook reads only the first value of a repeated header
+			if name := textproto.CanonicalMIMEHeaderKey(header.Name); !hasHeader(r, name) {
+				r.Header[name] = header.Value
+			}
+		}
+
+		if postData := entry.Request.PostData; postData != nil {
+			if _, ok := r.Header["Content-Type"]; !ok && postData.MimeType != "" {
+				r.Header["Content-Type"] = postData.MimeType
+			}
+
+			switch {
+			case postData.Text != "" && postData.Encoding == bodyEncodingBase64:
+				r.Body, r.BodyEncoding = postData.Text, bodyEncodingBase64
+			case postData.Text != "":
+				r.Body = postData.Text
+			case len(postData.Params) > 0:
+				values := url.Values{}
+
+				for _, param := range postData.Params {
+					values.Add(param.Name, param.Value)
+				}
+
+				r.Body = values.Encode()
+			}
+		}
+
+		requests = append(requests, r)
+	}
+
+	return requests, nil
+}
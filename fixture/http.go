@@ -0,0 +1,230 @@
+package fixture
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/textproto"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	httpMethods        = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "CONNECT", "TRACE"}
+	httpFileVariable   = regexp.MustCompile(`^@([A-Za-z0-9_.-]+)\s*=\s*(.*)$`)
+	httpFileReference  = regexp.MustCompile(`{{\s*([A-Za-z0-9_.-]+)\s*}}`)
+	httpFileSeparator  = "###"
+	httpFileBodySource = "< "
+)
+
+// parseRaw parses a raw HTTP request dump, everything after the blank line following
+// the headers is treated as the request body and kept byte for byte
+func parseRaw(input string) (*Request, error) {
+	head, body := splitRawRequest(strings.TrimLeft(input, "\r\n"))
+
+	lines := strings.Split(strings.TrimRight(strings.Replace(head, "\r\n", "\n", -1), "\n"), "\n")
+
+	r, err := parseRequestLine(lines[0])
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := parseHeaderLines(r, lines[1:]); err != nil {
+		return nil, err
+	}
+
+	if length, ok := r.Header["Content-Length"]; ok {
+		if n, err := strconv.Atoi(length); err == nil && n < len(body) {
+			body = body[:n]
+		}
+	}
+
+	r.SetBody([]byte(body))
+
+	return r, nil
+}
+
+// splitRawRequest splits the request at the first empty line, which may end with either
+// CRLF or LF
+func splitRawRequest(input string) (string, string) {
+	for pos := 0; ; {
+		idx := strings.IndexByte(input[pos:], '\n')
+
+		if idx < 0 {
+			return input, ""
+		}
+
+		pos += idx + 1
+
+		switch rest := input[pos:]; {
+		case strings.HasPrefix(rest, "\n"):
+			return input[:pos], rest[1:]
+		case strings.HasPrefix(rest, "\r\n"):
+			return input[:pos], rest[2:]
+		}
+	}
+}
+
+// parseHTTPFile parses an .http file containing one or more requests separated by ###,
+// with support for @name = value file variables, {{name}} references and < file bodies
+func parseHTTPFile(input, dir string) ([]*Request, error) {
+	var requests []*Request
+
+	variables := make(map[string]string)
+
+	for _, block := range splitHTTPFile(strings.Replace(input, "\r\n", "\n", -1)) {
+		var lines []string
+
+		for _, line := range strings.Split(block, "\n") {
+			trimmed := strings.TrimSpace(line)
+
+			if len(lines) == 0 {
+				if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
+					continue
+				}
+
+				if match := httpFileVariable.FindStringSubmatch(trimmed); match != nil {
+					variables[match[1]] = expandHTTPFileVariables(match[2], variables)
+					continue
+				}
+			}
+
+			lines = append(lines, expandHTTPFileVariables(line, variables))
+		}
+
+		if len(lines) == 0 {
+			continue
+		}
+
+		r, err := parseRequestLine(lines[0])
+
+		if err != nil {
+			return nil, fmt.Errorf("request %d: %s", len(requests)+1, err)
+		}
+
+		headerEnd := len(lines)
+
+		for i := 1; i < len(lines); i++ {
+			if strings.TrimSpace(lines[i]) == "" {
+				headerEnd = i
+				break
+			}
+		}
+
+		if err := parseHeaderLines(r, lines[1:headerEnd]); err != nil {
+			return nil, fmt.Errorf("request %d: %s", len(requests)+1, err)
+		}
+
+		var body string
+
+		if headerEnd < len(lines) {
+			body = strings.TrimRight(strings.Join(lines[headerEnd+1:], "\n"), " \t\n")
+		}
+
+		if strings.HasPrefix(body, httpFileBodySource) && !strings.Contains(body, "\n") {
+			path := strings.TrimSpace(strings.TrimPrefix(body, httpFileBodySource))
+
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+
+			data, err := ioutil.ReadFile(path)
+
+			if err != nil {
+				return nil, fmt.Errorf("request %d: %s", len(requests)+1, err)
+			}
+
+			r.SetBody(data)
+		} else {
+			r.SetBody([]byte(body))
+		}
+
+		requests = append(requests, r)
+	}
+
+	return requests, nil
+}
+
+func splitHTTPFile(input string) []string {
+	var blocks []string
+	var current []string
+
+	for _, line := range strings.Split(input, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), httpFileSeparator) {
+			blocks = append(blocks, strings.Join(current, "\n"))
+			current = nil
+			continue
+		}
+
+		current = append(current, line)
+	}
+
+	return append(blocks, strings.Join(current, "\n"))
+}
+
+func expandHTTPFileVariables(input string, variables map[string]string) string {
+	return httpFileReference.ReplaceAllStringFunc(input, func(reference string) string {
+		name := httpFileReference.FindStringSubmatch(reference)[1]
+
+		if value, ok := variables[name]; ok {
+			return value
+		}
+
+		return reference
+	})
+}
+
+func parseRequestLine(line string) (*Request, error) {
+	fields := strings.Fields(line)
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("missing request line")
+	}
+
+	method := "GET"
+
+	for _, m := range httpMethods {
+		if strings.ToUpper(fields[0]) == m {
+			method = m
+			fields = fields[1:]
+			break
+		}
+	}
+
+	if len(fields) == 0 || len(fields) > 2 || (len(fields) == 2 && !strings.HasPrefix(fields[1], "HTTP/")) {
+		return nil, fmt.Errorf("invalid request line %s", line)
+	}
+
+	return &Request{Method: method, URL: fields[0], Header: make(map[string]string)}, nil
+}
+
+func hasHeader(r *Request, name string) bool {
+	_, ok := r.Header[name]
+
+	return ok
+}
+
+func parseHeaderLines(r *Request, lines []string) error {
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		splitResult := strings.SplitN(line, ":", 2)
+
+		if len(splitResult) != 2 {
+			return fmt.Errorf("invalid header line %s", line)
+		}
+
+		name := textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(splitResult[0]))
+
+		// webhook reads only the first value of a repeated header
+		if !hasHeader(r, name) {
+			r.Header[name] = strings.TrimSpace(splitResult[1])
+		}
+	}
+
+	return nil
+}
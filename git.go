@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	commitChanges     bool
+	commitMessage     string
+	changedProperties []string
+)
+
+// describeChange sets the message the change is committed with when --commit is used
+func describeChange(command, id string) {
+	commitMessage = command + " " + id
+
+	if len(changedProperties) > 0 {
+		sort.SliceStable(changedProperties, func(i, j int) bool {
+			return strings.HasPrefix(changedProperties[i], "set ") && strings.HasPrefix(changedProperties[j], "unset ")
+		})
+
+		commitMessage += ": " + strings.Join(changedProperties, ", ")
+	}
+}
+
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], message)
+		}
+
+		return "", fmt.Errorf("git %s: %s", args[0], err)
+	}
+
+	return string(output), nil
+}
+
+// checkHooksFileCommitted returns an error if the hooks file is not in a git work tree
+// or has uncommitted changes that would end up in the commit
+func checkHooksFileCommitted(path string) error {
+	status, err := runGit(filepath.Dir(path), "status", "--porcelain", "--", filepath.Base(path))
+
+	if err != nil {
+		return err
+	}
+
+	if status != "" && !strings.HasPrefix(status, "??") {
+		return fmt.Errorf("hooks file %s has uncommitted changes\ncommit or discard them before using --commit", path)
+	}
+
+	return nil
+}
+
+// commitHooksFiles commits the given hooks files alone, leaving anything else staged untouched,
+// files in different repositories are committed in each of them with the same message
+func commitHooksFiles(paths []string, message string) error {
+	var repositories []string
+	names := make(map[string][]string)
+
+	for _, path := range paths {
+		name, err := filepath.Abs(path)
+
+		if err != nil {
+			return err
+		}
+
+		output, err := runGit(filepath.Dir(name), "rev-parse", "--show-toplevel")
+
+		if err != nil {
+			return err
+		}
+
+		repository := strings.TrimSpace(output)
+
+		if _, ok := names[repository]; !ok {
+			repositories = append(repositories, repository)
+		}
+
+		names[repository] = append(names[repository], name)
+	}
+
+	for _, repository := range repositories {
+		// git runs next to the files so that their paths need no resolving against the top level
+		dir := filepath.Dir(names[repository][0])
+
+		if _, err := runGit(dir, append([]string{"add", "--"}, names[repository]...)...); err != nil {
+			return err
+		}
+
+		if _, err := runGit(dir, append([]string{"commit", "--quiet", "--message", message, "--"}, names[repository]...)...); err != nil {
+			return err
+		}
+
+		if len(repositories) > 1 {
+			log.Printf("committed in %s: %s\n", repository, message)
+		} else {
+			log.Printf("committed: %s\n", message)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/adnanh/hookman/document"
+	"github.com/codegangsta/cli"
+)
+
+const (
+	historyDir  = ".hookman"
+	historyFile = "history"
+)
+
+// historyRecord is a single change of a hooks file as stored in the history journal
+type historyRecord struct {
+	Sequence   int          `json:"-"`
+	Time       time.Time    `json:"time"`
+	User       string       `json:"user"`
+	Command    []string     `json:"command"`
+	File       string       `json:"file"`
+	Changes    []hookChange `json:"changes"`
+	Undoes     []int        `json:"undoes,omitempty"`
+	FileBefore string       `json:"file-before"`
+	FileAfter  string       `json:"file-after"`
+}
+
+// historyListing is a history record in the log output
+type historyListing struct {
+	Sequence int             `json:"sequence"`
+	Time     time.Time       `json:"time"`
+	User     string          `json:"user"`
+	Command  []string        `json:"command"`
+	Undoes   []int           `json:"undoes,omitempty"`
+	Changes  []changeListing `json:"changes"`
+}
+
+// changeListing is a changed hook in the log output
+type changeListing struct {
+	ID     string          `json:"id"`
+	Index  int             `json:"index"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// hookChange holds the snapshots of a single hook before and after a change, the index
+// is the local index among the hooks with the same id
+type hookChange struct {
+	ID     string          `json:"id"`
+	Index  int             `json:"idx"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+func historyPath(path string) string {
+	return filepath.Join(filepath.Dir(path), historyDir, historyFile)
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+
+	return os.Getenv("USER")
+}
+
+// indexedHookDocuments returns the compact hook documents from the hooks file contents
+// keyed by id and local index, along with the keys in file order
+func indexedHookDocuments(data []byte, format string) (map[string][]byte, []hookChange) {
+	documents := make(map[string][]byte)
+	var keys []hookChange
+
+	if len(data) == 0 {
+		return documents, nil
+	}
+
+	converted, err := codecs[format].toJSON(parseableHooksData(data, format))
+
+	if err != nil {
+		return documents, nil
+	}
+
+	objects, err := document.UnmarshalArray(converted)
+
+	if err != nil {
+		return documents, nil
+	}
+
+	counts := make(map[string]int)
+
+	for _, o := range objects {
+		var h struct {
+			ID string `json:"id"`
+		}
+
+		o.Decode(&h)
+
+		encoded, _ := json.Marshal(o)
+		key := hookChange{ID: h.ID, Index: counts[h.ID]}
+
+		documents[fmt.Sprintf("%s\x00%d", key.ID, key.Index)] = encoded
+		keys = append(keys, key)
+		counts[h.ID]++
+	}
+
+	return documents, keys
+}
+
+// hookChanges returns the hooks that were added, removed or modified between the two
+// versions of the hooks file
+func hookChanges(before, after []byte, format string) []hookChange {
+	beforeDocuments, beforeKeys := indexedHookDocuments(before, format)
+	afterDocuments, afterKeys := indexedHookDocuments(after, format)
+
+	var changes []hookChange
+
+	for _, key := range afterKeys {
+		name := fmt.Sprintf("%s\x00%d", key.ID, key.Index)
+
+		if !bytes.Equal(beforeDocuments[name], afterDocuments[name]) {
+			key.Before, key.After = beforeDocuments[name], afterDocuments[name]
+			changes = append(changes, key)
+		}
+	}
+
+	for _, key := range beforeKeys {
+		name := fmt.Sprintf("%s\x00%d", key.ID, key.Index)
+
+		if _, ok := afterDocuments[name]; !ok {
+			key.Before = beforeDocuments[name]
+			changes = append(changes, key)
+		}
+	}
+
+	return changes
+}
+
+// appendHistory appends the change of the hooks file to the history journal
+func appendHistory(before, after []byte, undoes []int) error {
+	path := historyPath(hooksFile)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	record := historyRecord{
+		Time:       time.Now().UTC(),
+		User:       currentUser(),
+		Command:    append([]string{filepath.Base(os.Args[0])}, os.Args[1:]...),
+		File:       filepath.Base(hooksFile),
+		Changes:    hookChanges(before, after, hooksFormat),
+		Undoes:     undoes,
+		FileBefore: string(before),
+		FileAfter:  string(after),
+	}
+
+	data, err := json.Marshal(record)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(append(data, '\n'))
+
+	return err
+}
+
+// loadHistory returns the history records of the hooks file, oldest first
+func loadHistory() ([]*historyRecord, error) {
+	f, err := os.Open(historyPath(hooksFile))
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var records []*historyRecord
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 256*1024*1024)
+
+	for sequence := 1; scanner.Scan(); sequence++ {
+		record := &historyRecord{}
+
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			return nil, fmt.Errorf("invalid history record %d: %s", sequence, err)
+		}
+
+		record.Sequence = sequence
+
+		if record.File == filepath.Base(hooksFile) {
+			records = append(records, record)
+		}
+	}
+
+	return records, scanner.Err()
+}
+
+// undoableRecords returns the records that were not undone and are not undos themselves,
+// newest first
+func undoableRecords(records []*historyRecord) []*historyRecord {
+	undone := make(map[int]bool)
+	var result []*historyRecord
+
+	for i := len(records) - 1; i >= 0; i-- {
+		for _, sequence := range records[i].Undoes {
+			undone[sequence] = true
+		}
+
+		if len(records[i].Undoes) == 0 && !undone[records[i].Sequence] {
+			result = append(result, records[i])
+		}
+	}
+
+	return result
+}
+
+func (change hookChange) String() string {
+	switch {
+	case change.Before == nil:
+		return fmt.Sprintf("+ %s [%d]", change.ID, change.Index)
+	case change.After == nil:
+		return fmt.Sprintf("- %s [%d]", change.ID, change.Index)
+	default:
+		return fmt.Sprintf("~ %s [%d]", change.ID, change.Index)
+	}
+}
+
+func showHistory(c *cli.Context) {
+	hooksFile = singleHooksFile(c, "log")
+
+	records, err := loadHistory()
+
+	if err != nil {
+		log.Fatalf("error: could not load history: %s\n", err)
+	}
+
+	id := ""
+
+	if len(c.Args()) > 0 {
+		id = c.Args()[0]
+	}
+
+	format := outputFormat(c)
+	listings := make([]historyListing, 0)
+
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+
+		var changes []hookChange
+
+		for _, change := range record.Changes {
+			if id == "" || change.ID == id {
+				changes = append(changes, change)
+			}
+		}
+
+		if id != "" && len(changes) == 0 {
+			continue
+		}
+
+		if format == outputText {
+			log.Printf("#%d %s %s: %s\n", record.Sequence, record.Time.Local().Format("2006-01-02 15:04:05"), record.User, strings.Join(record.Command, " "))
+
+			if len(record.Undoes) > 0 {
+				log.Printf("   undoes %s\n", formatSequences(record.Undoes))
+			}
+
+			for _, change := range changes {
+				log.Printf("   %s\n", change)
+
+				if c.Bool("patch") && change.Before != nil {
+					log.Printf("       before: %s\n", change.Before)
+				}
+
+				if c.Bool("patch") && change.After != nil {
+					log.Printf("       after:  %s\n", change.After)
+				}
+			}
+
+			log.Println()
+		}
+
+		listing := historyListing{
+			Sequence: record.Sequence,
+			Time:     record.Time,
+			User:     record.User,
+			Command:  record.Command,
+			Undoes:   record.Undoes,
+			Changes:  make([]changeListing, len(changes)),
+		}
+
+		for i, change := range changes {
+			listing.Changes[i] = changeListing{ID: change.ID, Index: change.Index, Before: change.Before, After: change.After}
+		}
+
+		if listings = append(listings, listing); c.IsSet("limit") && len(listings) >= c.Int("limit") {
+			break
+		}
+	}
+
+	switch {
+	case format != outputText:
+		writeOutput(format, listings)
+	case len(listings) == 0:
+		log.Println("no history")
+	}
+}
+
+func formatSequences(sequences []int) string {
+	formatted := make([]string, len(sequences))
+
+	for i, sequence := range sequences {
+		formatted[i] = fmt.Sprintf("#%d", sequence)
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+func undoChanges(c *cli.Context) {
+	hooksFile = singleHooksFile(c, "undo")
+
+	format, err := detectFormat(c.GlobalString("format"), hooksFile)
+
+	if err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	hooksFormat = format
+
+	lockHooks(c)
+
+	count := 1
+
+	if len(c.Args()) > 0 {
+		if _, err := fmt.Sscanf(c.Args()[0], "%d", &count); err != nil || count < 1 {
+			log.Fatalln("error: number of changes to undo must be a positive number")
+		}
+	}
+
+	records, err := loadHistory()
+
+	if err != nil {
+		log.Fatalf("error: could not load history: %s\n", err)
+	}
+
+	undoable := undoableRecords(records)
+
+	if len(undoable) < count {
+		log.Fatalf("error: there are only %d change(s) that can be undone\n", len(undoable))
+	}
+
+	current, err := ioutil.ReadFile(hooksFile)
+
+	if err != nil && !os.IsNotExist(err) {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	if string(current) != records[len(records)-1].FileAfter && !c.Bool("force") {
+		log.Fatalln("error: hooks file was changed outside of hookman since the last recorded change\nuse --force to undo anyway")
+	}
+
+	var undoes []int
+
+	for _, record := range undoable[:count] {
+		log.Printf(" - undoing #%d: %s\n", record.Sequence, strings.Join(record.Command, " "))
+		undoes = append(undoes, record.Sequence)
+	}
+
+	hooksFileData = current
+
+	if err := writeHooksFileUndoing([]byte(undoable[count-1].FileBefore), undoes); err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUndoableRecords(t *testing.T) {
+	tests := []struct {
+		name   string
+		undoes [][]int
+		want   []int
+	}{
+		{"no records", nil, nil},
+		{"newest first", [][]int{nil, nil, nil}, []int{3, 2, 1}},
+		{"undone record", [][]int{nil, nil, {2}}, []int{1}},
+		{"undo of an older record", [][]int{nil, nil, {1}}, []int{2}},
+		{"undo of several records", [][]int{nil, nil, nil, {3, 2}}, []int{1}},
+		{"changes after an undo", [][]int{nil, {1}, nil}, []int{3}},
+		{"consecutive undos", [][]int{nil, nil, nil, {3}, {2}}, []int{1}},
+		{"everything undone", [][]int{nil, {1}}, nil},
+	}
+
+	for _, test := range tests {
+		var records []*historyRecord
+
+		for i, undoes := range test.undoes {
+			records = append(records, &historyRecord{Sequence: i + 1, Undoes: undoes})
+		}
+
+		var got []int
+
+		for _, record := range undoableRecords(records) {
+			got = append(got, record.Sequence)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestHookChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   []string
+	}{
+		{"unchanged", `[{"id":"a"}]`, `[{"id":"a"}]`, nil},
+		{"reformatted", `[{"id":"a","execute-command":"x"}]`, "[\n  {\n    \"id\": \"a\",\n    \"execute-command\": \"x\"\n  }\n]", nil},
+		{"added", `[{"id":"a"}]`, `[{"id":"a"},{"id":"b"}]`, []string{"+ b [0]"}},
+		{"removed", `[{"id":"a"},{"id":"b"}]`, `[{"id":"b"}]`, []string{"- a [0]"}},
+		{"modified", `[{"id":"a","execute-command":"x"}]`, `[{"id":"a","execute-command":"y"}]`, []string{"~ a [0]"}},
+		{"duplicate ids", `[{"id":"a"},{"id":"a","execute-command":"x"}]`, `[{"id":"a"},{"id":"a","execute-command":"y"}]`, []string{"~ a [1]"}},
+		{"new file", ``, `[{"id":"a"}]`, []string{"+ a [0]"}},
+	}
+
+	for _, test := range tests {
+		var got []string
+
+		for _, change := range hookChanges([]byte(test.before), []byte(test.after), formatJSON) {
+			got = append(got, change.String())
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
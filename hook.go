@@ -28,6 +28,26 @@ type Hook hook.Hook
 // CompactHook that implements Stringer interface
 type CompactHook hook.Hook
 
+const (
+	ansiReset    = "\x1b[0m"
+	ansiOperator = "\x1b[1;35m"
+	ansiSource   = "\x1b[36m"
+	ansiValue    = "\x1b[32m"
+	ansiRegex    = "\x1b[33m"
+	ansiSecret   = "\x1b[31m"
+)
+
+// colorOutput enables ANSI colors in the rules of the expanded hook view
+var colorOutput bool
+
+func colorize(color, s string) string {
+	if !colorOutput {
+		return s
+	}
+
+	return color + s + ansiReset
+}
+
 func (r OrRule) String() string {
 	stringSlice := make([]string, len(r))
 
@@ -35,21 +55,23 @@ func (r OrRule) String() string {
 		stringSlice[idx] = fmt.Sprintf("%s", (Rules)(rule))
 	}
 
-	return fmt.Sprintf("%s", strings.Join(stringSlice, " || "))
+	return fmt.Sprintf("%s", strings.Join(stringSlice, colorize(ansiOperator, " || ")))
 }
 
 func (r NotRule) String() string {
-	return fmt.Sprintf("!(%s)", (Rules)(r))
+	return fmt.Sprintf("%s(%s)", colorize(ansiOperator, "!"), (Rules)(r))
 }
 
 func (r MatchRule) String() string {
+	parameter := fmt.Sprintf("\"%s.%s\"", colorize(ansiSource, r.Parameter.Source), r.Parameter.Name)
+
 	switch {
 	case r.Type == hook.MatchValue:
-		return fmt.Sprintf("\"%s.%s\" == \"%s\"", r.Parameter.Source, r.Parameter.Name, r.Value)
+		return fmt.Sprintf("%s %s \"%s\"", parameter, colorize(ansiOperator, "=="), colorize(ansiValue, r.Value))
 	case r.Type == hook.MatchRegex:
-		return fmt.Sprintf("\"%s.%s\" ~= \"%s\"", r.Parameter.Source, r.Parameter.Name, r.Regex)
+		return fmt.Sprintf("%s %s \"%s\"", parameter, colorize(ansiOperator, "~="), colorize(ansiRegex, r.Regex))
 	case r.Type == hook.MatchHashSHA1:
-		return fmt.Sprintf("\"%s.%s\" == sha1(\"payload\", \"%s\")", r.Parameter.Source, r.Parameter.Name, r.Secret)
+		return fmt.Sprintf("%s %s %s(\"payload\", \"%s\")", parameter, colorize(ansiOperator, "=="), colorize(ansiOperator, "sha1"), colorize(ansiSecret, r.Secret))
 	default:
 		return "<INVALID MATCH RULE>"
 	}
@@ -77,7 +99,40 @@ func (r *AndRule) String() string {
 		stringSlice[idx] = fmt.Sprintf("%s", Rules(rule))
 	}
 
-	return fmt.Sprintf("%s", strings.Join(stringSlice, " && "))
+	return fmt.Sprintf("%s", strings.Join(stringSlice, colorize(ansiOperator, " && ")))
+}
+
+// tree returns the rule as an indented tree with the logical operators as branches and
+// the match rules as leaves, prefix is written before every line but the first
+func (r Rules) tree(prefix string) string {
+	switch {
+	case r.And != nil:
+		return colorize(ansiOperator, "and") + ruleBranches(*r.And, prefix)
+	case r.Or != nil:
+		return colorize(ansiOperator, "or") + ruleBranches(*r.Or, prefix)
+	case r.Not != nil:
+		return colorize(ansiOperator, "not") + ruleBranches([]hook.Rules{hook.Rules(*r.Not)}, prefix)
+	case r.Match != nil:
+		return fmt.Sprintf("%s", (*MatchRule)(r.Match))
+	default:
+		return "<NO RULE>"
+	}
+}
+
+func ruleBranches(rules []hook.Rules, prefix string) string {
+	var result []string
+
+	for idx, rule := range rules {
+		branch, indent := "├── ", "│   "
+
+		if idx == len(rules)-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		result = append(result, fmt.Sprintf("\n%s%s%s", prefix, branch, Rules(rule).tree(prefix+indent)))
+	}
+
+	return strings.Join(result, "")
 }
 
 func (h Hook) String() string {
@@ -106,7 +161,7 @@ func (h Hook) String() string {
 	}
 
 	if h.TriggerRule != nil {
-		result = append(result, fmt.Sprintf("TRIGGER RULE:\n   %s\n\n", (*Rules)(h.TriggerRule)))
+		result = append(result, fmt.Sprintf("TRIGGER RULE:\n   %s\n\n", Rules(*h.TriggerRule).tree("   ")))
 	}
 
 	if h.ResponseMessage != "" {
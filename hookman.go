@@ -1,14 +1,19 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"reflect"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/adnanh/hookman/document"
+	"github.com/adnanh/hookman/fixture"
 	"github.com/adnanh/hookman/parser"
 	"github.com/adnanh/webhook/hook"
 	"github.com/codegangsta/cli"
@@ -26,14 +31,29 @@ var (
 		},
 	}
 
-	hooks     hook.Hooks
-	hooksIds  []string
-	hooksMap  = make(map[string][]*hook.Hook)
-	hooksFile string
+	hooks         hook.Hooks
+	hookSources   []hookSource
+	hooksIds      []string
+	hooksMap      = make(map[string][]*hook.Hook)
+	hooksFile     string
+	hooksFormat   string
+	hooksFileData []byte
+	sourceFiles   []*sourceFile
+	backupCount   int
+	recordHistory bool
 )
 
+// hookSource holds the file and the document a hook was loaded from and its position in
+// that file, hooks added or moved to a file since loading have a negative index
+type hookSource struct {
+	file     *sourceFile
+	document *document.Object
+	index    int
+}
+
 func deleteHooks(hooksToDelete []*hook.Hook) {
 	var newHooks hook.Hooks
+	var newSources []hookSource
 
 	for i := 0; i < len(hooks); i++ {
 		found := false
@@ -46,19 +66,123 @@ func deleteHooks(hooksToDelete []*hook.Hook) {
 
 		if !found {
 			newHooks = append(newHooks, hooks[i])
+			newSources = append(newSources, hookSources[i])
 		}
 	}
 
 	hooks = newHooks
+	hookSources = newSources
 }
 
 func loadHooks(c *cli.Context) error {
-	hooksFile = c.GlobalString("file")
+	paths, err := hooksFilePaths(c)
+
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if findSourceFile(path) != nil {
+			continue
+		}
+
+		if _, err := loadHooksFile(c.GlobalString("format"), path, nil); err != nil {
+			return err
+		}
+	}
+
+	indexHooks()
+	lockLoadedHooksFiles()
 
-	if err := hooks.LoadFromFile(hooksFile); err != nil {
-		return fmt.Errorf("could not load hooks from file: %s\n", err)
+	return nil
+}
+
+// loadHooksFile appends the hooks from the given file and the files it includes to the
+// loaded hooks, including holds the files that include it
+func loadHooksFile(requestedFormat, path string, including []string) (*sourceFile, error) {
+	f := &sourceFile{path: path}
+
+	var data []byte
+	var documents []*document.Object
+	var fileHooks hook.Hooks
+	var err error
+
+	if path == stdioFile {
+		f.data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		f.data, err = ioutil.ReadFile(path)
+	}
+
+	if path == stdioFile && requestedFormat == "" {
+		f.format = sniffFormat(f.data)
+	} else if format, formatErr := detectFormat(requestedFormat, path); formatErr != nil {
+		return nil, formatErr
+	} else {
+		f.format = format
 	}
 
+	if os.IsNotExist(err) && path != stdioFile {
+		// registered so that touch can create the file
+		sourceFiles = append(sourceFiles, f)
+	}
+
+	if err == nil {
+		f.contents = parseableHooksData(f.data, f.format)
+		data, err = codecs[f.format].toJSON(f.contents)
+	}
+
+	if err == nil {
+		documents, err = document.UnmarshalArray(data)
+	}
+
+	if err == nil {
+		fileHooks = make(hook.Hooks, len(documents))
+
+		for i := 0; i < len(documents) && err == nil; i++ {
+			err = decodeHook(documents[i], &fileHooks[i])
+		}
+	}
+
+	if err != nil && !templateMode && bytes.Contains(f.data, []byte("{{")) {
+		return nil, fmt.Errorf("could not load hooks from file %s: %s\nuse --template for hooks files with webhook template actions\n", path, err)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not load hooks from file %s: %s\n", path, err)
+	}
+
+	sourceFiles = append(sourceFiles, f)
+
+	for i := range fileHooks {
+		pattern, ok, err := parseIncludeDirective(documents[i])
+
+		if err != nil {
+			return nil, fmt.Errorf("could not load hooks from file %s: %s\n", path, err)
+		}
+
+		if ok {
+			f.includes = append(f.includes, includeDirective{index: i, document: documents[i], pattern: pattern})
+			continue
+		}
+
+		hooks = append(hooks, fileHooks[i])
+		hookSources = append(hookSources, hookSource{file: f, document: documents[i], index: i})
+	}
+
+	for i := range f.includes {
+		if err := loadIncludedFiles(f, &f.includes[i], append(including, path)); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// indexHooks groups the loaded hooks by id
+func indexHooks() {
+	hooksIds = nil
+	hooksMap = make(map[string][]*hook.Hook)
+
 	for i := 0; i < len(hooks); i++ {
 		h := &hooks[i]
 
@@ -70,58 +194,170 @@ func loadHooks(c *cli.Context) error {
 	}
 
 	sort.Strings(hooksIds)
-
-	return nil
 }
 
-func saveHooks() error {
-	var formattedOutput []byte
-	var err error
+// formatHooks returns the contents of the given hooks file, unless reformat is requested
+// only the hooks that changed since loading are rewritten and the rest of the file is
+// kept as is
+func formatHooks(f *sourceFile, reformat bool) ([]byte, error) {
+	elements := fileElements(f)
 
-	if len(hooks) == 0 {
-		formattedOutput = []byte("[]\n")
-	} else {
-		formattedOutput, err = json.MarshalIndent(hooks, "", "  ")
+	if len(elements) == 0 && (reformat || len(f.contents) == 0) {
+		return referenceSchema(f, []byte("[]\n")), nil
+	}
+
+	entries := make([]patchEntry, len(elements))
+	documents := make([]*document.Object, len(elements))
+
+	for i, element := range elements {
+		if element.directive != nil {
+			entries[i] = patchEntry{index: element.directive.index, document: element.directive.document}
+			documents[i] = element.directive.document
+			continue
+		}
+
+		merged, err := mergeHookDocument(element.source.document, element.hook)
 
 		if err != nil {
-			return fmt.Errorf("could not format hooks file: %s\n", err)
+			return nil, err
+		}
+
+		entries[i] = patchEntry{index: element.source.index, document: merged, changed: true}
+		documents[i] = merged
+
+		if original := element.source.document; original != nil && element.source.index >= 0 {
+			before, _ := json.Marshal(original)
+			after, _ := json.Marshal(merged)
+			entries[i].changed = !bytes.Equal(before, after)
 		}
 	}
 
-	fileInfo, err := os.Stat(hooksFile)
-	var fileMode os.FileMode
+	if !reformat {
+		var patched []byte
+		ok := false
+
+		switch f.format {
+		case formatJSON:
+			patched, ok = patchJSON(f.contents, entries)
+		case formatJSON5:
+			patched, ok = patchJSON5(f.contents, entries)
+		case formatYAML:
+			patched, ok = patchYAML(f.contents, entries)
+		}
 
-	if err != nil {
-		fileMode = os.FileMode(0644)
-	} else {
-		fileMode = fileInfo.Mode()
+		if ok {
+			return referenceSchema(f, restoreTemplateActions(patched)), nil
+		}
 	}
 
-	err = ioutil.WriteFile(hooksFile, formattedOutput, fileMode.Perm())
+	if len(elements) == 0 {
+		return referenceSchema(f, []byte("[]\n")), nil
+	}
+
+	output, err := codecs[f.format].marshal(documents)
 
 	if err != nil {
-		return fmt.Errorf("could not create hooks file: %s\n", err)
+		return nil, err
+	}
+
+	return referenceSchema(f, restoreTemplateActions(output)), nil
+}
+
+// saveHooks writes every hooks file whose hooks changed, see formatHooks for the meaning
+// of reformat
+func saveHooks(reformat bool) error {
+	var changedFiles []*sourceFile
+	var outputs [][]byte
+
+	for _, f := range sourceFiles {
+		formattedOutput, err := formatHooks(f, reformat)
+
+		if err != nil {
+			return fmt.Errorf("could not format hooks file %s: %s\n", f.path, err)
+		}
+
+		if f.path == stdioFile {
+			_, err := os.Stdout.Write(formattedOutput)
+			return err
+		}
+
+		if f.data != nil && bytes.Equal(formattedOutput, f.data) {
+			continue
+		}
+
+		changedFiles = append(changedFiles, f)
+		outputs = append(outputs, formattedOutput)
 	}
 
-	log.Println("ok")
+	if len(changedFiles) == 0 {
+		log.Println("ok")
+		return nil
+	}
+
+	var paths []string
+
+	for _, f := range changedFiles {
+		if err := checkHooksFileUnchanged(f); err != nil {
+			return err
+		}
+
+		if commitChanges && commitMessage != "" {
+			if err := checkHooksFileCommitted(f.path); err != nil {
+				return err
+			}
+		}
+
+		paths = append(paths, f.path)
+	}
+
+	for i, f := range changedFiles {
+		hooksFile, hooksFormat, hooksFileData = f.path, f.format, f.data
+
+		if err := writeHooksFile(outputs[i]); err != nil {
+			return err
+		}
+	}
+
+	if renderFile != "" {
+		if err := writeRenderedHooks(renderFile, nil); err != nil {
+			return err
+		}
+	}
+
+	if commitChanges && commitMessage != "" {
+		return commitHooksFiles(paths, commitMessage)
+	}
 
 	return nil
 }
 
 func formatHooksFile(c *cli.Context) {
+	lockHooks(c)
+
 	if err := loadHooks(c); err != nil {
 		log.Fatalf("error: %s\n", err)
 	}
 
-	if err := saveHooks(); err != nil {
+	if err := saveHooks(true); err != nil {
 		log.Fatalf("error: %s\n", err)
 	}
 }
 
 func printHook(h *hook.Hook, idx int, compact bool) {
-	if compact {
+	path := ""
+
+	if len(sourceFiles) > 1 {
+		path = hookSources[hookIndex(h)].file.path
+	}
+
+	switch {
+	case compact && path != "":
+		log.Printf("  %s (%s)\n\n", fmt.Sprintf((*CompactHook)(h).String(), idx), path)
+	case compact:
 		log.Printf("  %s\n\n", fmt.Sprintf((*CompactHook)(h).String(), idx))
-	} else {
+	case path != "":
+		log.Printf("INDEX:\n   %d\n\nFILE:\n   %s\n\n%s\n", idx, path, (*Hook)(h))
+	default:
 		log.Printf("INDEX:\n   %d\n\n%s\n", idx, (*Hook)(h))
 	}
 }
@@ -137,8 +373,41 @@ func listHooks(c *cli.Context) {
 		log.Fatalf("error: %s\n", err)
 	}
 
+	if c.Bool("resolve") {
+		if err := resolveHooks(); err != nil {
+			log.Fatalf("error: could not resolve variables: %s\n", err)
+		}
+	}
+
+	if c.Bool("table") {
+		if c.IsSet("format") || outputFormat(c) != outputText {
+			log.Fatalln("error: use only one of --table, --format and --output")
+		}
+
+		writeHooksTable(c)
+		return
+	}
+
+	if c.IsSet("format") {
+		if outputFormat(c) != outputText {
+			log.Fatalln("error: use only one of --table, --format and --output")
+		}
+
+		writeHooksReport(c, c.String("format"))
+		return
+	}
+
+	if format := outputFormat(c); format != outputText {
+		writeHooksOutput(c, format)
+		return
+	}
+
 	terminateOnEmptyHooksFile()
 
+	if _, terminal := terminalWidth(os.Stderr); terminal && !c.GlobalBool("no-color") && os.Getenv("NO_COLOR") == "" {
+		colorOutput = true
+	}
+
 	expanded := c.Bool("expanded")
 
 	if len(c.Args()) == 0 {
@@ -149,7 +418,7 @@ func listHooks(c *cli.Context) {
 			}
 		}
 
-		log.Printf("total %d hook(s) in file: %s\n", len(hooks), hooksFile)
+		log.Printf("total %d hook(s) in %s\n", len(hooks), describeHooksFiles())
 	} else {
 		// user supplied hook id, print only hooks matching the given id
 
@@ -174,13 +443,15 @@ func listHooks(c *cli.Context) {
 				printHook(h, idx, compact)
 			}
 
-			log.Printf("total %d hook(s) with ID %s in file: %s\n", len(hooksSlice), c.Args()[0], hooksFile)
+			log.Printf("total %d hook(s) with ID %s in %s\n", len(hooksSlice), c.Args()[0], describeHooksFiles())
 		}
 
 	}
 }
 
 func addHook(c *cli.Context) {
+	lockHooks(c)
+
 	if err := loadHooks(c); err != nil {
 		log.Fatalf("error: %s\n", err)
 	}
@@ -195,14 +466,27 @@ func addHook(c *cli.Context) {
 		log.Fatalf("error: cannot set property: %s\n", err)
 	}
 
+	target := sourceFiles[0]
+
+	if c.IsSet("to") {
+		if target = findSourceFile(c.String("to")); target == nil {
+			log.Fatalf("error: %s is not one of the given hooks files\n", c.String("to"))
+		}
+	}
+
 	hooks = append(hooks, newHook)
+	hookSources = append(hookSources, hookSource{file: target, index: -1})
 
-	if err := saveHooks(); err != nil {
+	describeChange("add", newHook.ID)
+
+	if err := saveHooks(false); err != nil {
 		log.Fatalf("error: %s\n", err)
 	}
 }
 
 func deleteHook(c *cli.Context) {
+	lockHooks(c)
+
 	if err := loadHooks(c); err != nil {
 		log.Fatalf("error: %s\n", err)
 	}
@@ -233,7 +517,37 @@ func deleteHook(c *cli.Context) {
 
 	deleteHooks(hooksToBeDeleted)
 
-	saveHooks()
+	if len(hooksToBeDeleted) > 1 {
+		describeChange("delete", fmt.Sprintf("%s (%d hooks)", c.Args()[0], len(hooksToBeDeleted)))
+	} else {
+		describeChange("delete", c.Args()[0])
+	}
+
+	if err := saveHooks(false); err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+}
+
+// propertyAliases maps the short property names accepted by --set and --unset to the
+// property names used in the hooks file
+var propertyAliases = map[string]string{
+	"cmd":              "execute-command",
+	"rule":             "trigger-rule",
+	"cwd":              "command-working-directory",
+	"message":          "response-message",
+	"env":              "pass-environment-to-command",
+	"args":             "pass-arguments-to-command",
+	"json-params":      "parse-parameters-as-json",
+	"include-response": "include-command-output-in-response",
+}
+
+// canonicalProperty returns the hooks file name of the given lowercased property name
+func canonicalProperty(property string) string {
+	if name, ok := propertyAliases[property]; ok {
+		return name
+	}
+
+	return property
 }
 
 func setHookProperties(h *hook.Hook, propertyValuePairs []string) error {
@@ -244,7 +558,8 @@ func setHookProperties(h *hook.Hook, propertyValuePairs []string) error {
 			log.Fatalln("error: --set must follow property=newvalue format")
 		}
 
-		property, value := strings.ToLower(splitResult[0]), splitResult[1]
+		property, value := strings.ToLower(splitResult[0]), protectTemplateValue(splitResult[1])
+		changedProperties = append(changedProperties, "set "+canonicalProperty(property))
 
 		switch {
 		case property == "id":
@@ -340,14 +655,31 @@ func setHookProperties(h *hook.Hook, propertyValuePairs []string) error {
 		default:
 			return fmt.Errorf("invalid property name %s", property)
 		}
+
+		if name := canonicalProperty(property); templatedHookProperty(h, name) && hookPropertyEmpty(h, name) {
+			return fmt.Errorf("%s holds a template action, edit the hooks file to replace it with an empty value", name)
+		}
 	}
 
 	return nil
 }
 
+// hookPropertyEmpty reports whether the given property of the hook has its zero value
+func hookPropertyEmpty(h *hook.Hook, property string) bool {
+	field := reflect.ValueOf(h).Elem().Field(hookProperties[property].field)
+
+	return reflect.DeepEqual(field.Interface(), reflect.Zero(field.Type()).Interface())
+}
+
 func unsetHookProperties(h *hook.Hook, properties []string) error {
 	for _, property := range properties {
 		property = strings.ToLower(property)
+		changedProperties = append(changedProperties, "unset "+canonicalProperty(property))
+
+		if name := canonicalProperty(property); templatedHookProperty(h, name) {
+			return fmt.Errorf("%s holds a template action, edit the hooks file to remove it", name)
+		}
+
 		switch {
 		case property == "id":
 			log.Fatalln("error: property id is required")
@@ -423,18 +755,24 @@ func findHooksByID(c *cli.Context) ([]*hook.Hook, error) {
 }
 
 func findOneHookByID(c *cli.Context) (*hook.Hook, error) {
-	hooksSlice, err := findHooksByID(c)
+	if len(c.Args()) == 0 {
+		return nil, fmt.Errorf("you must specify a valid hook id")
+	}
 
-	if err != nil {
-		return nil, err
+	return findOneHook(c.Args()[0], c.IsSet("idx"), c.Int("idx"))
+}
+
+func findOneHook(id string, idxSet bool, idx int) (*hook.Hook, error) {
+	hooksSlice, ok := hooksMap[id]
+
+	if !ok {
+		return nil, fmt.Errorf("could not find any hooks matching the given id")
 	}
 
-	if !c.IsSet("idx") && len(hooksSlice) > 1 {
+	if !idxSet && len(hooksSlice) > 1 {
 		return nil, fmt.Errorf("there are %d hook(s) matching the given id\nuse --idx index to specify the one you want to modify", len(hooksSlice))
 	}
 
-	idx := c.Int("idx")
-
 	if idx >= len(hooksSlice) || idx < 0 {
 		return nil, fmt.Errorf("given local hook index is out of bounds")
 	}
@@ -443,6 +781,8 @@ func findOneHookByID(c *cli.Context) (*hook.Hook, error) {
 }
 
 func editHook(c *cli.Context) {
+	lockHooks(c)
+
 	if err := loadHooks(c); err != nil {
 		log.Fatalf("error: %s\n", err)
 	}
@@ -463,18 +803,31 @@ func editHook(c *cli.Context) {
 		log.Fatalf("error: cannot set property: %s\n", err)
 	}
 
-	if err := saveHooks(); err != nil {
+	if c.IsSet("idx") {
+		describeChange("edit", fmt.Sprintf("%s[%d]", c.Args()[0], c.Int("idx")))
+	} else {
+		describeChange("edit", c.Args()[0])
+	}
+
+	if err := saveHooks(false); err != nil {
 		log.Fatalf("error: %s\n", err)
 	}
 }
 
 func touchHooksFile(c *cli.Context) {
-	if err := loadHooks(c); err != nil {
-		if err := saveHooks(); err != nil {
-			log.Fatalf("error: %s\n", err)
-		}
-	} else {
+	lockHooks(c)
+
+	err := loadHooks(c)
+
+	switch {
+	case err == nil:
 		log.Fatalln("error: hooks file already exists")
+	case len(sourceFiles) == 0 || sourceFiles[len(sourceFiles)-1].data != nil:
+		log.Fatalf("error: %s\n", err)
+	}
+
+	if err := saveHooks(true); err != nil {
+		log.Fatalf("error: %s\n", err)
 	}
 }
 
@@ -490,12 +843,84 @@ func main() {
 	app.Authors = authors
 	app.Usage = "manage webhook hooks file"
 	app.Flags = []cli.Flag{
-		cli.StringFlag{
+		cli.StringSliceFlag{
 			Name:   "file, f",
-			Value:  "hooks.json",
-			Usage:  "path to the hooks file",
+			Usage:  "path to the hooks file (" + defaultHooksFile + " by default), can be given more than once and can be a directory or a glob, " + stdioFile + " reads it from stdin and writes the result to stdout",
 			EnvVar: "HOOKS_FILE",
 		},
+		cli.StringFlag{
+			Name:   "format",
+			Usage:  "hooks file format, json, json5 or yaml (detected from the file extension by default)",
+			EnvVar: "HOOKS_FORMAT",
+		},
+		cli.IntFlag{
+			Name:   "backups",
+			Value:  3,
+			Usage:  "number of rotating backups of the hooks file to keep, 0 disables them",
+			EnvVar: "HOOKS_BACKUPS",
+		},
+		cli.DurationFlag{
+			Name:   "lock-timeout",
+			Value:  10 * time.Second,
+			Usage:  "how long to wait for other hookman invocations modifying the hooks file",
+			EnvVar: "HOOKS_LOCK_TIMEOUT",
+		},
+		cli.BoolFlag{
+			Name:   "no-history",
+			Usage:  "do not record changes in the " + historyDir + "/" + historyFile + " journal next to the hooks file",
+			EnvVar: "HOOKMAN_NO_HISTORY",
+		},
+		cli.BoolFlag{
+			Name:   "commit",
+			Usage:  "commit the hooks file to git after every add, edit and delete",
+			EnvVar: "HOOKMAN_COMMIT",
+		},
+		cli.StringFlag{
+			Name:   "render",
+			Usage:  "path to the flat JSON hooks file rendered from the hooks files and their includes, kept in sync on every change",
+			EnvVar: "HOOKS_RENDER",
+		},
+		cli.BoolFlag{
+			Name:   "template",
+			Usage:  "treat the hooks files as webhook -template files and keep their {{ }} actions as they are",
+			EnvVar: "HOOKS_TEMPLATE",
+		},
+		cli.BoolFlag{
+			Name:  "no-color",
+			Usage: "do not color the output even when writing to a terminal (also disabled by setting NO_COLOR)",
+		},
+		cli.BoolFlag{
+			Name:   "substitute",
+			Usage:  "fill the ${VAR} placeholders of the rendered hooks from the .env file next to the hooks file and the environment (implied by --vars and --env-file)",
+			EnvVar: "HOOKS_SUBSTITUTE",
+		},
+		cli.StringSliceFlag{
+			Name:   "vars",
+			Usage:  "JSON, YAML or .env file with the values of the ${VAR} placeholders, takes precedence over the .env file and the environment",
+			EnvVar: "HOOKS_VARS",
+		},
+		cli.StringFlag{
+			Name:   "env-file",
+			Usage:  ".env file with the values of the ${VAR} placeholders (.env next to the hooks file by default)",
+			EnvVar: "HOOKS_ENV_FILE",
+		},
+	}
+
+	app.Before = func(c *cli.Context) error {
+		backupCount = c.GlobalInt("backups")
+		recordHistory = !c.GlobalBool("no-history")
+		commitChanges = c.GlobalBool("commit")
+		renderFile = c.GlobalString("render")
+		templateMode = c.GlobalBool("template")
+		varsFiles = c.GlobalStringSlice("vars")
+		envFile = c.GlobalString("env-file")
+		substituteVars = c.GlobalBool("substitute")
+
+		if templateMode {
+			log.SetOutput(templateOutput{os.Stderr})
+		}
+
+		return nil
 	}
 
 	app.Commands = []cli.Command{
@@ -513,6 +938,28 @@ func main() {
 					Name:  "compact, c",
 					Usage: "print compact version of hook(s) matching the given id",
 				},
+				cli.BoolFlag{
+					Name:  "resolve, r",
+					Usage: "show the hooks with the ${VAR} placeholders filled in",
+				},
+				outputFlag,
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "Go template executed for every hook with the hook fields, .File, .Index and .Position, and the rule, args, env, mask, json, expanded and compact functions",
+				},
+				cli.BoolFlag{
+					Name:  "table, t",
+					Usage: "print the hooks as a table fitted to the terminal width",
+				},
+				cli.StringFlag{
+					Name:  "columns",
+					Value: "id,idx,command,has-rule",
+					Usage: "comma separated table columns, id, idx, command, cwd, has-rule, rule, args, capture-output or file",
+				},
+				cli.StringFlag{
+					Name:  "sort",
+					Usage: "table column to sort by, prefixed with - for descending order",
+				},
 				cli.IntFlag{
 					Name:  "idx, i",
 					Usage: "local hook index (used for differentiating multiple hooks with the same id)",
@@ -525,6 +972,29 @@ func main() {
 			Usage:   "cleans up and reindents hooks file",
 			Action:  formatHooksFile,
 		},
+		{
+			Name:   "convert",
+			Usage:  "converts the given hooks file to another format, or checks that two files hold the same hooks",
+			Action: convertHooksFile,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "from",
+					Usage: "input file format (detected from the file extension by default)",
+				},
+				cli.StringFlag{
+					Name:  "to",
+					Usage: "output file format (detected from the file extension by default)",
+				},
+				cli.BoolFlag{
+					Name:  "check, c",
+					Usage: "only check that both files hold semantically identical hooks",
+				},
+				cli.BoolFlag{
+					Name:  "force",
+					Usage: "overwrite the output file if it exists",
+				},
+			},
+		},
 		{
 			Name:    "edit",
 			Aliases: []string{"e", "modify", "mod"},
@@ -556,6 +1026,26 @@ func main() {
 					Name:  "set, s",
 					Usage: "property=value",
 				},
+				cli.StringFlag{
+					Name:  "to",
+					Usage: "hooks file to add the hook to when several are given (the first one by default)",
+				},
+			},
+		},
+		{
+			Name:   "move",
+			Usage:  "moves the hook matching the given id to another hooks file",
+			Action: moveHook,
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "idx, i",
+					Value: 0,
+					Usage: "local hook index (used for differentiating multiple hooks with the same id)",
+				},
+				cli.StringFlag{
+					Name:  "to",
+					Usage: "hooks file to move the hook to",
+				},
 			},
 		},
 		{
@@ -564,6 +1054,50 @@ func main() {
 			Usage:   "creates an empty hooks file if it does not already exist",
 			Action:  touchHooksFile,
 		},
+		{
+			Name:   "restore",
+			Usage:  "restores the hooks file from one of its backups",
+			Action: restoreHooksFile,
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "backup, b",
+					Value: 1,
+					Usage: "number of the backup to restore, 1 being the most recent one",
+				},
+				cli.BoolFlag{
+					Name:  "list, l",
+					Usage: "list the available backups",
+				},
+				outputFlag,
+			},
+		},
+		{
+			Name:   "log",
+			Usage:  "shows the recorded changes of the hooks file, or of the hooks matching the given id",
+			Action: showHistory,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "patch, p",
+					Usage: "show the hooks before and after every change",
+				},
+				cli.IntFlag{
+					Name:  "limit, n",
+					Usage: "show only the given number of most recent changes",
+				},
+				outputFlag,
+			},
+		},
+		{
+			Name:   "undo",
+			Usage:  "reverts the given number of most recent changes of the hooks file (1 by default)",
+			Action: undoChanges,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "force",
+					Usage: "undo even if the hooks file was changed outside of hookman",
+				},
+			},
+		},
 		{
 			Name:    "delete",
 			Aliases: []string{"del", "remove", "rm"},
@@ -581,6 +1115,177 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:   "render",
+			Usage:  "writes the hooks files with their includes resolved as a single flat JSON hooks file",
+			Action: renderHooksFile,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "out, o",
+					Usage: "path to the rendered hooks file (the --render path or stdout by default)",
+				},
+				cli.StringSliceFlag{
+					Name:  "env, e",
+					Usage: "environment whose overlay (hooks.<env>.json next to hooks.json) is applied, can be given more than once",
+				},
+			},
+		},
+		{
+			Name:   "diff",
+			Usage:  "shows how the rendered hooks of two environments, or of an environment and the base hooks, differ",
+			Action: diffEnvironments,
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "env, e",
+					Usage: "environment to compare, given once or twice",
+				},
+				outputFlag,
+			},
+		},
+		{
+			Name:   "validate",
+			Usage:  "checks the hooks for problems, exits with 0 if there are no errors, 1 if there are and 2 if the hooks cannot be loaded",
+			Action: validateHooks,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "strict",
+					Usage: "exit with 1 on warnings as well",
+				},
+				cli.BoolFlag{
+					Name:  "no-filesystem",
+					Usage: "skip the checks of the commands and working directories on disk",
+				},
+				outputFlag,
+			},
+		},
+		{
+			Name:   "schema",
+			Usage:  "prints the JSON Schema of the hooks file for editor validation and autocompletion",
+			Action: printSchema,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "write, w",
+					Usage: "reference the schema at the given path or URL from the YAML hooks files instead",
+				},
+			},
+		},
+		{
+			Name:   "record",
+			Usage:  "runs a proxy in front of webhook that records every delivery for later replay",
+			Action: recordDeliveries,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "listen, l",
+					Value: ":9001",
+					Usage: "address the recording proxy listens on",
+				},
+				cli.StringFlag{
+					Name:  "upstream, u",
+					Usage: "url of the running webhook the deliveries are forwarded to",
+				},
+				cli.StringFlag{
+					Name:  "out, o",
+					Value: "deliveries.jsonl",
+					Usage: "path to the JSON lines file the deliveries are appended to",
+				},
+				cli.StringFlag{
+					Name:  "prefix, p",
+					Value: fixture.DefaultHooksPrefix,
+					Usage: "url prefix webhook serves the hooks under",
+				},
+				cli.StringSliceFlag{
+					Name:  "redact, r",
+					Usage: "header name whose value is replaced with REDACTED in the recording",
+				},
+			},
+		},
+		{
+			Name:   "replay",
+			Usage:  "evaluates recorded deliveries against the hooks file and reports the ones that would match differently",
+			Action: replayDeliveries,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "hook",
+					Usage: "replay only the deliveries for the hook with the given id",
+				},
+				cli.IntFlag{
+					Name:  "idx, i",
+					Usage: "local hook index (used for differentiating multiple hooks with the same id)",
+				},
+				cli.StringSliceFlag{
+					Name:  "set, s",
+					Usage: "property=value to try on the --hook before replaying, the hooks file is not modified",
+				},
+				cli.BoolFlag{
+					Name:  "verbose, v",
+					Usage: "print every replayed delivery, not just the ones that would match differently",
+				},
+				outputFlag,
+			},
+		},
+		{
+			Name:   "sign",
+			Usage:  "prints the payload signature expected by the payload-hash-sha1 rule of the given hook",
+			Action: signPayload,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "hook",
+					Usage: "id of the hook whose secret is used for signing",
+				},
+				cli.IntFlag{
+					Name:  "idx, i",
+					Usage: "local hook index (used for differentiating multiple hooks with the same id)",
+				},
+				cli.StringFlag{
+					Name:  "payload, p",
+					Usage: "path to the payload file to sign",
+				},
+			},
+		},
+		{
+			Name:   "fire",
+			Usage:  "sends a signed request satisfying the trigger rule of the given hook to a running webhook",
+			Action: fireHook,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "url",
+					Usage: "url of the hook, defaults to " + defaultWebhookURL + "<id>",
+				},
+				cli.IntFlag{
+					Name:  "idx, i",
+					Usage: "local hook index (used for differentiating multiple hooks with the same id)",
+				},
+				cli.StringFlag{
+					Name:  "payload, p",
+					Usage: "path to the JSON payload file to send",
+				},
+			},
+		},
+		{
+			Name:   "curl",
+			Usage:  "prints an example curl command that satisfies the trigger rule of the given hook",
+			Action: printCurlCommand,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "base-url, b",
+					Value: "http://localhost:9000",
+					Usage: "url webhook is served at",
+				},
+				cli.StringFlag{
+					Name:  "prefix",
+					Value: fixture.DefaultHooksPrefix,
+					Usage: "url prefix webhook serves the hooks under",
+				},
+				cli.IntFlag{
+					Name:  "idx, i",
+					Usage: "local hook index (used for differentiating multiple hooks with the same id)",
+				},
+				cli.StringFlag{
+					Name:  "payload, p",
+					Usage: "path to the JSON payload file used as a base for the example",
+				},
+			},
+		},
 	}
 
 	app.Run(os.Args)
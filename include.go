@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adnanh/hookman/document"
+	"github.com/adnanh/webhook/hook"
+	"github.com/codegangsta/cli"
+)
+
+// includeKey is the key of the hooks file elements that include other hooks files, the
+// value is a path or a glob relative to the including file
+const includeKey = "include"
+
+var renderFile string
+
+// includeDirective is an element of a hooks file that includes other hooks files
+type includeDirective struct {
+	index    int
+	document *document.Object
+	pattern  string
+	files    []*sourceFile
+}
+
+// fileElement is either a hook or an include directive of a hooks file
+type fileElement struct {
+	hook      *hook.Hook
+	source    hookSource
+	directive *includeDirective
+}
+
+// parseIncludeDirective returns the pattern of the element if it is an include directive
+func parseIncludeDirective(o *document.Object) (string, bool, error) {
+	raw, ok := o.Get(includeKey)
+
+	if !ok {
+		return "", false, nil
+	}
+
+	if _, ok := o.Get("id"); ok {
+		return "", false, nil
+	}
+
+	var pattern string
+
+	if err := o.Decode(&struct {
+		Include *string `json:"include"`
+	}{&pattern}); err != nil || pattern == "" {
+		return "", true, fmt.Errorf("%s must be a path or a glob, got %s", includeKey, raw)
+	}
+
+	return pattern, true, nil
+}
+
+// loadIncludedFiles loads the hooks files included by the given directive of f, the
+// including files are used to detect include cycles
+func loadIncludedFiles(f *sourceFile, directive *includeDirective, including []string) error {
+	pattern := directive.pattern
+
+	if !filepath.IsAbs(pattern) && f.path != stdioFile {
+		pattern = filepath.Join(filepath.Dir(f.path), pattern)
+	}
+
+	paths := []string{pattern}
+
+	if strings.ContainsAny(pattern, "*?[") {
+		matches, err := filepath.Glob(pattern)
+
+		if err != nil {
+			return fmt.Errorf("invalid include pattern %s in %s: %s", directive.pattern, f.path, err)
+		}
+
+		paths = paths[:0]
+
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && !info.IsDir() {
+				paths = append(paths, match)
+			}
+		}
+	}
+
+	for _, path := range paths {
+		path = filepath.Clean(path)
+
+		for _, parent := range including {
+			if parent == path {
+				return fmt.Errorf("include cycle: %s includes %s", strings.Join(including, " includes "), path)
+			}
+		}
+
+		included := findSourceFile(path)
+
+		if included == nil {
+			var err error
+
+			if included, err = loadHooksFile("", path, including); err != nil {
+				return err
+			}
+		}
+
+		included.included = true
+
+		directive.files = append(directive.files, included)
+	}
+
+	return nil
+}
+
+// fileElements returns the hooks and the include directives of the given file in the order
+// they are written in it
+func fileElements(f *sourceFile) []fileElement {
+	var elements []fileElement
+	next := 0
+
+	for i := range hooks {
+		if hookSources[i].file != f {
+			continue
+		}
+
+		for index := hookSources[i].index; next < len(f.includes) && (index < 0 || f.includes[next].index < index); next++ {
+			elements = append(elements, fileElement{directive: &f.includes[next]})
+		}
+
+		elements = append(elements, fileElement{hook: &hooks[i], source: hookSources[i]})
+	}
+
+	for ; next < len(f.includes); next++ {
+		elements = append(elements, fileElement{directive: &f.includes[next]})
+	}
+
+	return elements
+}
+
+// renderDocuments returns the documents of all hooks, with the include directives replaced
+// by the hooks of the included files
+func renderDocuments() ([]*document.Object, error) {
+	var documents []*document.Object
+
+	var render func(f *sourceFile) error
+
+	render = func(f *sourceFile) error {
+		for _, element := range fileElements(f) {
+			if element.directive != nil {
+				for _, included := range element.directive.files {
+					if err := render(included); err != nil {
+						return err
+					}
+				}
+
+				continue
+			}
+
+			merged, err := mergeHookDocument(element.source.document, element.hook)
+
+			if err != nil {
+				return err
+			}
+
+			documents = append(documents, merged)
+		}
+
+		return nil
+	}
+
+	for _, f := range sourceFiles {
+		if !f.included && !f.detached {
+			if err := render(f); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return documents, nil
+}
+
+// renderHooks returns the flat JSON hooks file webhook loads, with the overlays of the
+// given environments applied
+func renderHooks(envs []string) ([]byte, error) {
+	documents, err := environmentDocuments(envs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(documents) == 0 {
+		return []byte("[]\n"), nil
+	}
+
+	output, err := codecs[formatJSON].marshal(documents)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return restoreTemplateActions(output), nil
+}
+
+// writeRenderedHooks writes the rendered hooks to the given path
+func writeRenderedHooks(path string, envs []string) error {
+	data, err := renderHooks(envs)
+
+	if err != nil {
+		return fmt.Errorf("could not render hooks: %s\n", err)
+	}
+
+	if err := writeFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("could not write rendered hooks file: %s\n", err)
+	}
+
+	log.Printf("rendered %s\n", path)
+
+	return nil
+}
+
+func renderHooksFile(c *cli.Context) {
+	if err := loadHooks(c); err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	path := c.String("out")
+
+	if path == "" {
+		path = renderFile
+	}
+
+	if path == "" || path == stdioFile {
+		data, err := renderHooks(c.StringSlice("env"))
+
+		if err != nil {
+			log.Fatalf("error: could not render hooks: %s\n", err)
+		}
+
+		os.Stdout.Write(data)
+
+		return
+	}
+
+	if err := writeRenderedHooks(path, c.StringSlice("env")); err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+}
@@ -0,0 +1,514 @@
+package json5
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/adnanh/hookman/document"
+)
+
+const (
+	errorUnexpectedCharacter = "unexpected character %q at line %d"
+	errorUnexpectedEOF       = "unexpected end of input at line %d"
+	errorInvalidNumber       = "invalid number %s at line %d"
+	errorInvalidEscape       = "invalid escape sequence at line %d"
+)
+
+type converter struct {
+	input    []rune
+	position int
+	line     int
+	out      bytes.Buffer
+
+	// depth and the rune positions of the top level array, used by Layout
+	depth    int
+	open     int
+	close    int
+	elements [][2]int
+}
+
+// ToJSON converts the given JSON5 document to plain JSON, comments are dropped
+func ToJSON(data []byte) ([]byte, error) {
+	c, err := convert(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.out.Bytes(), nil
+}
+
+// Layout returns the layout of the top level array in the given JSON5 document, the
+// offsets point into data so that unchanged elements can be kept with their comments
+func Layout(data []byte) (*document.ArrayLayout, error) {
+	c, err := convert(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if c.out.Len() == 0 || c.out.Bytes()[0] != '[' {
+		return nil, fmt.Errorf("expected JSON5 array")
+	}
+
+	// byte offset of every rune, and of the end of the input
+	offsets := make([]int, 0, len(c.input)+1)
+	offset := 0
+
+	for _, ch := range c.input {
+		offsets = append(offsets, offset)
+		offset += utf8.RuneLen(ch)
+	}
+
+	offsets = append(offsets, offset)
+
+	layout := &document.ArrayLayout{Open: offsets[c.open], Close: offsets[c.close]}
+
+	for _, element := range c.elements {
+		layout.Elements = append(layout.Elements, document.Span{Start: offsets[element[0]], End: offsets[element[1]]})
+	}
+
+	return layout, nil
+}
+
+func convert(data []byte) (*converter, error) {
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("input is not valid UTF-8")
+	}
+
+	c := &converter{input: []rune(string(data)), line: 1}
+
+	if err := c.skipWhitespace(); err != nil {
+		return nil, err
+	}
+
+	if err := c.value(); err != nil {
+		return nil, err
+	}
+
+	if err := c.skipWhitespace(); err != nil {
+		return nil, err
+	}
+
+	if !c.eof() {
+		return nil, c.unexpected()
+	}
+
+	return c, nil
+}
+
+func (c *converter) eof() bool {
+	return c.position >= len(c.input)
+}
+
+func (c *converter) peek() rune {
+	if c.eof() {
+		return 0
+	}
+
+	return c.input[c.position]
+}
+
+func (c *converter) next() rune {
+	ch := c.peek()
+
+	if ch == '\n' {
+		c.line++
+	}
+
+	c.position++
+
+	return ch
+}
+
+func (c *converter) hasPrefix(prefix string) bool {
+	end := c.position + len(prefix)
+
+	if end > len(c.input) {
+		return false
+	}
+
+	return string(c.input[c.position:end]) == prefix
+}
+
+func (c *converter) unexpected() error {
+	if c.eof() {
+		return fmt.Errorf(errorUnexpectedEOF, c.line)
+	}
+
+	return fmt.Errorf(errorUnexpectedCharacter, c.peek(), c.line)
+}
+
+func (c *converter) skipWhitespace() error {
+	for !c.eof() {
+		switch {
+		case unicode.IsSpace(c.peek()) || c.peek() == '\uFEFF':
+			c.next()
+		case c.hasPrefix("//"):
+			for !c.eof() && c.peek() != '\n' {
+				c.next()
+			}
+		case c.hasPrefix("/*"):
+			c.position += 2
+
+			for !c.hasPrefix("*/") {
+				if c.eof() {
+					return fmt.Errorf(errorUnexpectedEOF, c.line)
+				}
+
+				c.next()
+			}
+
+			c.position += 2
+		default:
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (c *converter) value() error {
+	c.depth++
+	defer func() { c.depth-- }()
+
+	switch ch := c.peek(); {
+	case ch == '{':
+		return c.object()
+	case ch == '[':
+		return c.array()
+	case ch == '"' || ch == '\'':
+		s, err := c.string()
+
+		if err != nil {
+			return err
+		}
+
+		return c.writeString(s)
+	case ch == '-' || ch == '+' || ch == '.' || unicode.IsDigit(ch) || ch == 'I' || ch == 'N':
+		return c.number()
+	case c.hasPrefix("true"), c.hasPrefix("false"), c.hasPrefix("null"):
+		word := c.identifier()
+
+		if word != "true" && word != "false" && word != "null" {
+			return fmt.Errorf(errorUnexpectedCharacter, word, c.line)
+		}
+
+		c.out.WriteString(word)
+
+		return nil
+	}
+
+	return c.unexpected()
+}
+
+func (c *converter) object() error {
+	c.next()
+	c.out.WriteByte('{')
+
+	for first := true; ; first = false {
+		if err := c.skipWhitespace(); err != nil {
+			return err
+		}
+
+		if c.peek() == '}' {
+			c.next()
+			c.out.WriteByte('}')
+			return nil
+		}
+
+		if !first {
+			c.out.WriteByte(',')
+		}
+
+		var key string
+		var err error
+
+		if ch := c.peek(); ch == '"' || ch == '\'' {
+			key, err = c.string()
+		} else if key = c.identifier(); key == "" {
+			err = c.unexpected()
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := c.writeString(key); err != nil {
+			return err
+		}
+
+		if err := c.skipWhitespace(); err != nil {
+			return err
+		}
+
+		if c.peek() != ':' {
+			return c.unexpected()
+		}
+
+		c.next()
+		c.out.WriteByte(':')
+
+		if err := c.skipWhitespace(); err != nil {
+			return err
+		}
+
+		if err := c.value(); err != nil {
+			return err
+		}
+
+		if err := c.skipWhitespace(); err != nil {
+			return err
+		}
+
+		switch c.peek() {
+		case ',':
+			c.next()
+		case '}':
+		default:
+			return c.unexpected()
+		}
+	}
+}
+
+func (c *converter) array() error {
+	top := c.depth == 1
+
+	if top {
+		c.open = c.position
+	}
+
+	c.next()
+	c.out.WriteByte('[')
+
+	for first := true; ; first = false {
+		if err := c.skipWhitespace(); err != nil {
+			return err
+		}
+
+		if c.peek() == ']' {
+			if top {
+				c.close = c.position
+			}
+
+			c.next()
+			c.out.WriteByte(']')
+			return nil
+		}
+
+		if !first {
+			c.out.WriteByte(',')
+		}
+
+		start := c.position
+
+		if err := c.value(); err != nil {
+			return err
+		}
+
+		if top {
+			c.elements = append(c.elements, [2]int{start, c.position})
+		}
+
+		if err := c.skipWhitespace(); err != nil {
+			return err
+		}
+
+		switch c.peek() {
+		case ',':
+			c.next()
+		case ']':
+		default:
+			return c.unexpected()
+		}
+	}
+}
+
+func (c *converter) identifier() string {
+	start := c.position
+
+	for !c.eof() {
+		ch := c.peek()
+
+		if !(ch == '_' || ch == '$' || unicode.IsLetter(ch) || (c.position > start && unicode.IsDigit(ch))) {
+			break
+		}
+
+		c.next()
+	}
+
+	return string(c.input[start:c.position])
+}
+
+func (c *converter) string() (string, error) {
+	var b strings.Builder
+
+	quote := c.next()
+
+	for {
+		if c.eof() {
+			return "", fmt.Errorf(errorUnexpectedEOF, c.line)
+		}
+
+		ch := c.next()
+
+		switch {
+		case ch == quote:
+			return b.String(), nil
+		case ch == '\n':
+			return "", fmt.Errorf(errorUnexpectedCharacter, ch, c.line-1)
+		case ch != '\\':
+			b.WriteRune(ch)
+			continue
+		}
+
+		if c.eof() {
+			return "", fmt.Errorf(errorUnexpectedEOF, c.line)
+		}
+
+		switch escaped := c.next(); escaped {
+		case 'b':
+			b.WriteRune('\b')
+		case 'f':
+			b.WriteRune('\f')
+		case 'n':
+			b.WriteRune('\n')
+		case 'r':
+			b.WriteRune('\r')
+		case 't':
+			b.WriteRune('\t')
+		case 'v':
+			b.WriteRune('\v')
+		case '0':
+			b.WriteRune(0)
+		case '\n', '\u2028', '\u2029':
+		case '\r':
+			if c.peek() == '\n' {
+				c.next()
+			}
+		case 'x', 'u':
+			digits := 2
+
+			if escaped == 'u' {
+				digits = 4
+			}
+
+			code, err := c.hexCode(digits)
+
+			if err != nil {
+				return "", err
+			}
+
+			// a high surrogate followed by an escaped low one encodes a single character
+			if escaped == 'u' && utf16.IsSurrogate(code) && c.hasPrefix("\\u") {
+				position := c.position
+				c.position += 2
+
+				if low, err := c.hexCode(4); err == nil && utf16.DecodeRune(code, low) != unicode.ReplacementChar {
+					code = utf16.DecodeRune(code, low)
+				} else {
+					c.position = position
+				}
+			}
+
+			b.WriteRune(code)
+		default:
+			b.WriteRune(escaped)
+		}
+	}
+}
+
+// hexCode reads the given number of hexadecimal digits of an escape sequence
+func (c *converter) hexCode(digits int) (rune, error) {
+	if c.position+digits > len(c.input) {
+		return 0, fmt.Errorf(errorInvalidEscape, c.line)
+	}
+
+	code, err := strconv.ParseUint(string(c.input[c.position:c.position+digits]), 16, 32)
+
+	if err != nil {
+		return 0, fmt.Errorf(errorInvalidEscape, c.line)
+	}
+
+	c.position += digits
+
+	return rune(code), nil
+}
+
+func (c *converter) writeString(s string) error {
+	encoded, err := json.Marshal(s)
+
+	if err != nil {
+		return err
+	}
+
+	c.out.Write(encoded)
+
+	return nil
+}
+
+func (c *converter) number() error {
+	start := c.position
+
+	for !c.eof() {
+		ch := c.peek()
+
+		if !(ch == '+' || ch == '-' || ch == '.' || ch == 'x' || ch == 'X' || unicode.IsDigit(ch) || unicode.IsLetter(ch)) {
+			break
+		}
+
+		c.next()
+	}
+
+	literal := string(c.input[start:c.position])
+	unsigned := strings.TrimLeft(literal, "+-")
+	negative := strings.HasPrefix(literal, "-")
+
+	switch {
+	case unsigned == "Infinity" || unsigned == "NaN":
+		return fmt.Errorf("%s at line %d cannot be represented in JSON", literal, c.line)
+	case strings.HasPrefix(unsigned, "0x") || strings.HasPrefix(unsigned, "0X"):
+		value, err := strconv.ParseUint(unsigned[2:], 16, 64)
+
+		if err != nil {
+			return fmt.Errorf(errorInvalidNumber, literal, c.line)
+		}
+
+		if negative {
+			c.out.WriteByte('-')
+		}
+
+		c.out.WriteString(strconv.FormatUint(value, 10))
+
+		return nil
+	}
+
+	if strings.HasPrefix(unsigned, ".") {
+		unsigned = "0" + unsigned
+	}
+
+	if strings.HasSuffix(unsigned, ".") {
+		unsigned += "0"
+	}
+
+	unsigned = strings.Replace(unsigned, ".e", ".0e", 1)
+	unsigned = strings.Replace(unsigned, ".E", ".0E", 1)
+
+	if negative {
+		unsigned = "-" + unsigned
+	}
+
+	if !json.Valid([]byte(unsigned)) {
+		return fmt.Errorf(errorInvalidNumber, literal, c.line)
+	}
+
+	c.out.WriteString(unsigned)
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package json5
+
+import "testing"
+
+func TestToJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain json", `{"a": [1, "b", true, null]}`, `{"a":[1,"b",true,null]}`},
+		{"unquoted keys", `{a: 1, $b_c: 2}`, `{"a":1,"$b_c":2}`},
+		{"single quotes", `['it\'s', '"quoted"']`, `["it's","\"quoted\""]`},
+		{"escapes", `["\n\r\t\v\0", "\x41B", "\q", "\u00e9"]`, `["\n\r\t\u000b\u0000","AB","q","é"]`},
+		{"line continuation", "['a\\\nb', 'c\\\r\nd']", `["ab","cd"]`},
+		{"surrogate pair", `{a: "\ud83d\ude00", b: '\uD83D\uDE00!'}`, `{"a":"😀","b":"😀!"}`},
+		{"lone high surrogate", `["\ud83d", "\ud83dx"]`, `["�","�x"]`},
+		{"high surrogate before a non surrogate", `["\ud83dA", "\ud83d\u0041"]`, `["�A","�A"]`},
+		{"hex numbers", `[0x1F, -0X10, +0xff]`, `[31,-16,255]`},
+		{"leading and trailing dots", `[.5, 5., -.5e2, 5.e1, +1]`, `[0.5,5.0,-0.5e2,5.0e1,1]`},
+		{"trailing commas", `{a: [1, 2,], b: {c: 3,},}`, `{"a":[1,2],"b":{"c":3}}`},
+		{"comments", "// head\n[1, /* inline */ 2 // tail\n]\n/* end */", `[1,2]`},
+	}
+
+	for _, test := range tests {
+		got, err := ToJSON([]byte(test.input))
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+
+		if string(got) != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestToJSONErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"unterminated string", `['a`},
+		{"newline in string", "['a\nb']"},
+		{"short unicode escape", `['\u12']`},
+		{"invalid hex escape", `['\xZZ']`},
+		{"infinity", `[Infinity]`},
+		{"nan", `[NaN]`},
+		{"invalid hex number", `[0xZ]`},
+		{"unterminated comment", `[1 /* 2 ]`},
+		{"trailing data", `[1] 2`},
+		{"double comma", `[1,,2]`},
+	}
+
+	for _, test := range tests {
+		if got, err := ToJSON([]byte(test.input)); err == nil {
+			t.Errorf("%s: expected an error, got %s", test.name, got)
+		}
+	}
+}
+
+func TestLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		elements []string
+	}{
+		{"empty", "[]", nil},
+		{"comments", "// head\n[\n  // a\n  {a: 1}, /* b */ {b: [2, 3]},\n]\n", []string{"{a: 1}", "{b: [2, 3]}"}},
+		{"strings", `['é]', "\"]" , 3]`, []string{`'é]'`, `"\"]"`, "3"}},
+	}
+
+	for _, test := range tests {
+		layout, err := Layout([]byte(test.input))
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+
+		var elements []string
+
+		for _, span := range layout.Elements {
+			elements = append(elements, test.input[span.Start:span.End])
+		}
+
+		if test.input[layout.Open] != '[' || test.input[layout.Close] != ']' || len(elements) != len(test.elements) {
+			t.Errorf("%s: got %q, brackets at %d and %d", test.name, elements, layout.Open, layout.Close)
+			continue
+		}
+
+		for i := range elements {
+			if elements[i] != test.elements[i] {
+				t.Errorf("%s: got %q, want %q", test.name, elements, test.elements)
+				break
+			}
+		}
+	}
+
+	if _, err := Layout([]byte("{a: [1]}")); err == nil {
+		t.Errorf("object: expected an error")
+	}
+}
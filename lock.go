@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/adnanh/webhook/hook"
+	"github.com/codegangsta/cli"
+)
+
+const lockRetryInterval = 50 * time.Millisecond
+
+var errLocked = fmt.Errorf("file is locked")
+
+// lockPath returns the lock file of the given hooks file, symlinks are resolved first so
+// that every path leading to the same file shares the lock
+func lockPath(path string) (string, error) {
+	resolved, err := resolveSymlinks(path)
+
+	if err != nil {
+		return "", err
+	}
+
+	dir, base := filepath.Split(resolved)
+
+	return filepath.Join(dir, "."+base+".lock"), nil
+}
+
+// lockedFiles holds the absolute paths of the lock files taken by this process
+var lockedFiles = make(map[string]bool)
+
+// lockTimeout is the timeout of the locks taken by lockHooks, hooksLocked is set once it
+// was called
+var (
+	lockTimeout time.Duration
+	hooksLocked bool
+)
+
+// lockHooksFile takes an exclusive advisory lock on the given hooks file, waiting up to
+// timeout for other hookman invocations to release it, the lock is held until the
+// process exits
+func lockHooksFile(path string, timeout time.Duration) error {
+	lock, err := lockPath(path)
+
+	if err != nil {
+		return err
+	}
+
+	key, err := filepath.Abs(lock)
+
+	if err != nil {
+		return err
+	}
+
+	if lockedFiles[key] {
+		return nil
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		err := tryLockFile(lock)
+
+		if err == nil {
+			lockedFiles[key] = true
+		}
+
+		if err != errLocked {
+			return err
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("another hookman is modifying %s, gave up after waiting %s", path, timeout)
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// lockHooksFiles locks the given files in the sorted order of the files they resolve to, so
+// that two hookman invocations locking the same files cannot deadlock
+func lockHooksFiles(paths []string, timeout time.Duration) {
+	var resolved []string
+
+	for _, path := range paths {
+		if path != stdioFile {
+			if target, err := resolveSymlinks(path); err == nil {
+				path = target
+			}
+		}
+
+		resolved = append(resolved, path)
+	}
+
+	paths = resolved
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if path == stdioFile {
+			continue
+		}
+
+		if err := lockHooksFile(path, timeout); err != nil {
+			log.Fatalf("error: could not lock hooks file: %s\n", err)
+		}
+	}
+}
+
+// lockHooks locks the hooks files, the files they include and the rendered hooks file for
+// the load, modify and save cycle of a mutating command
+func lockHooks(c *cli.Context) {
+	paths, err := hooksFilePaths(c)
+
+	if err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	paths = append(includedHooksFiles(c, paths), paths...)
+
+	if renderFile != "" {
+		paths = append(paths, renderFile)
+	}
+
+	lockTimeout, hooksLocked = c.GlobalDuration("lock-timeout"), true
+	lockHooksFiles(paths, lockTimeout)
+}
+
+// includedHooksFiles returns the files included by the given hooks files, the hooks are
+// loaded only to resolve the includes and are discarded, stdin cannot be read twice so
+// its includes are left to lockLoadedHooksFiles
+func includedHooksFiles(c *cli.Context, paths []string) []string {
+	for _, path := range paths {
+		if path == stdioFile {
+			return nil
+		}
+	}
+
+	defer func(loadedHooks hook.Hooks, loadedSources []hookSource, loadedFiles []*sourceFile) {
+		hooks, hookSources, sourceFiles = loadedHooks, loadedSources, loadedFiles
+		indexHooks()
+	}(hooks, hookSources, sourceFiles)
+
+	hooks, hookSources, sourceFiles = nil, nil, nil
+
+	var included []string
+
+	// files that cannot be loaded are reported by the load that follows the locking
+	loadHooks(c)
+
+	for _, f := range sourceFiles {
+		if f.included {
+			included = append(included, f.path)
+		}
+	}
+
+	return included
+}
+
+// lockLoadedHooksFiles locks the loaded files that lockHooks did not know of, because their
+// include directives were added after the locks were taken
+func lockLoadedHooksFiles() {
+	if !hooksLocked {
+		return
+	}
+
+	var paths []string
+
+	for _, f := range sourceFiles {
+		paths = append(paths, f.path)
+	}
+
+	lockHooksFiles(paths, lockTimeout)
+}
+
+// checkHooksFileUnchanged returns an error if the hooks file on disk is no longer the one
+// that was loaded, so that changes made by other tools are not overwritten
+func checkHooksFileUnchanged(f *sourceFile) error {
+	data, err := ioutil.ReadFile(f.path)
+
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if !bytes.Equal(data, f.data) {
+		return fmt.Errorf("hooks file %s changed on disk since it was loaded, not saving\n", f.path)
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFiles keeps the locked files open, closing them would release the locks
+var lockFiles []*os.File
+
+// tryLockFile takes an exclusive flock on the given file without waiting
+func tryLockFile(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+
+	if err != nil {
+		return err
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+
+		if err == syscall.EWOULDBLOCK {
+			return errLocked
+		}
+
+		return err
+	}
+
+	lockFiles = append(lockFiles, f)
+
+	return nil
+}
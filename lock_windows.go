@@ -0,0 +1,38 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"syscall"
+)
+
+// errorSharingViolation is returned when the file is opened by another process
+const errorSharingViolation syscall.Errno = 32
+
+// lockHandles keeps the locked files open, closing them would release the locks
+var lockHandles []syscall.Handle
+
+// tryLockFile opens the given file without sharing it with other processes, the lock is
+// released by the system when the process exits
+func tryLockFile(path string) error {
+	name, err := syscall.UTF16PtrFromString(path)
+
+	if err != nil {
+		return err
+	}
+
+	handle, err := syscall.CreateFile(name, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil, syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+
+	if err == errorSharingViolation {
+		return errLocked
+	}
+
+	if err != nil {
+		return err
+	}
+
+	lockHandles = append(lockHandles, handle)
+
+	return nil
+}
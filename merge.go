@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/adnanh/hookman/document"
+	"github.com/adnanh/webhook/hook"
+)
+
+type hookProperty struct {
+	name  string
+	field int
+}
+
+// hookProperties maps the lowercased JSON property names of hook.Hook to the struct fields,
+// keys are matched case-insensitively the same way encoding/json matches them
+var hookProperties = func() map[string]hookProperty {
+	properties := make(map[string]hookProperty)
+	t := reflect.TypeOf(hook.Hook{})
+
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+
+		if name == "" {
+			name = t.Field(i).Name
+		}
+
+		properties[strings.ToLower(name)] = hookProperty{name: name, field: i}
+	}
+
+	return properties
+}()
+
+// mergeHookDocument returns the original document of the hook with only the properties
+// that no longer match the hook replaced, unknown properties and the key order are kept
+func mergeHookDocument(original *document.Object, h *hook.Hook) (*document.Object, error) {
+	updated, err := document.FromValue(h)
+
+	if err != nil || original == nil {
+		return updated, err
+	}
+
+	result := document.New()
+	value := reflect.ValueOf(h).Elem()
+	seen := make(map[string]bool)
+
+	for _, key := range original.Keys {
+		raw := original.Values[key]
+		property, known := hookProperties[strings.ToLower(key)]
+
+		if !known {
+			result.Set(key, raw)
+			continue
+		}
+
+		seen[property.name] = true
+
+		field := value.Field(property.field)
+		decoded := reflect.New(field.Type())
+
+		if err := json.Unmarshal(raw, decoded.Interface()); err == nil && reflect.DeepEqual(decoded.Elem().Interface(), field.Interface()) {
+			result.Set(key, raw)
+			continue
+		}
+
+		// templated properties are not decoded into the hook, they stay until set
+		if templatedProperty(key, raw) && reflect.DeepEqual(field.Interface(), reflect.Zero(field.Type()).Interface()) {
+			result.Set(key, raw)
+			continue
+		}
+
+		if updatedRaw, ok := updated.Get(property.name); ok {
+			result.Set(key, updatedRaw)
+		}
+	}
+
+	for _, key := range updated.Keys {
+		if !seen[key] {
+			result.Set(key, updated.Values[key])
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/adnanh/hookman/document"
+	"github.com/adnanh/webhook/hook"
+	"github.com/codegangsta/cli"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// outputFlag selects the output format of the read commands
+var outputFlag = cli.StringFlag{
+	Name:  "output, o",
+	Value: outputText,
+	Usage: "output format, text, json or yaml (json and yaml are written to stdout)",
+}
+
+// outputFormat returns the output format requested with --output
+func outputFormat(c *cli.Context) string {
+	format := strings.ToLower(c.String("output"))
+
+	switch format {
+	case outputText, outputJSON, outputYAML:
+		return format
+	case "yml":
+		return outputYAML
+	default:
+		log.Fatalf("error: unsupported output format %s\n", c.String("output"))
+		return ""
+	}
+}
+
+// writeOutput writes the value to stdout as JSON or YAML
+func writeOutput(format string, v interface{}) {
+	data, err := encodeJSON(v, "", "  ")
+
+	if err == nil {
+		data = restoreTemplateStrings(data)
+	}
+
+	if err == nil && format == outputYAML {
+		data, err = jsonToYAML(data)
+	}
+
+	if err != nil {
+		log.Fatalf("error: could not encode output: %s\n", err)
+	}
+
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+
+	os.Stdout.Write(data)
+}
+
+// hookListing is a hook in the list output, index is the local index among the hooks with
+// the same id and position is the position of the hook in its file
+type hookListing struct {
+	File     string           `json:"file"`
+	Index    int              `json:"index"`
+	Position int              `json:"position"`
+	Rule     string           `json:"rule,omitempty"`
+	Hook     *document.Object `json:"hook"`
+}
+
+// selectedHooks returns the hooks the list command was asked for, in file order
+func selectedHooks(c *cli.Context) []*hook.Hook {
+	var selected []*hook.Hook
+
+	switch {
+	case len(c.Args()) == 0:
+		for i := range hooks {
+			selected = append(selected, &hooks[i])
+		}
+	case c.IsSet("idx"):
+		h, err := findOneHookByID(c)
+
+		if err != nil {
+			log.Fatalf("error: %s\n", err)
+		}
+
+		selected = append(selected, h)
+	default:
+		hooksSlice, err := findHooksByID(c)
+
+		if err != nil {
+			log.Fatalf("error: %s\n", err)
+		}
+
+		selected = hooksSlice
+	}
+
+	return selected
+}
+
+func writeHooksOutput(c *cli.Context, format string) {
+	selected := selectedHooks(c)
+	listings := make([]hookListing, 0, len(selected))
+
+	for _, h := range selected {
+		source := hookSources[hookIndex(h)]
+		merged, err := mergeHookDocument(source.document, h)
+
+		if err != nil {
+			log.Fatalf("error: %s\n", err)
+		}
+
+		listing := hookListing{File: source.file.path, Index: localIndex(h), Position: source.index, Hook: merged}
+
+		if h.TriggerRule != nil {
+			listing.Rule = fmt.Sprintf("%s", (*Rules)(h.TriggerRule))
+		}
+
+		listings = append(listings, listing)
+	}
+
+	writeOutput(format, listings)
+}
+
+// localIndex returns the index of the hook among the hooks with the same id
+func localIndex(h *hook.Hook) int {
+	for idx, other := range hooksMap[h.ID] {
+		if other == h {
+			return idx
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/adnanh/hookman/document"
+	"github.com/codegangsta/cli"
+)
+
+// overlayKeyPattern matches the overlay keys that select a hook by its id and local index
+var overlayKeyPattern = regexp.MustCompile(`^(.*)\[([0-9]+)\]$`)
+
+// overlayPaths returns the overlay files of the given environment, the overlay of
+// hooks.json for prod is hooks.prod.json, or the same name with any other hooks file extension
+func overlayPaths(env string) ([]string, error) {
+	if env == "" || strings.ContainsAny(env, `/\`) {
+		return nil, fmt.Errorf("invalid environment name %q", env)
+	}
+
+	var paths, expected []string
+
+	for _, f := range sourceFiles {
+		if f.included || f.detached || f.path == stdioFile {
+			continue
+		}
+
+		ext := filepath.Ext(f.path)
+		base := strings.TrimSuffix(f.path, ext)
+
+		expected = append(expected, base+"."+env+ext)
+
+		for _, candidate := range []string{ext, ".json", ".json5", ".yaml", ".yml"} {
+			if _, err := os.Stat(base + "." + env + candidate); err == nil {
+				paths = append(paths, base+"."+env+candidate)
+				break
+			}
+		}
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no overlay for environment %s, expected %s", env, strings.Join(expected, " or "))
+	}
+
+	return paths, nil
+}
+
+// isOverlayFile returns true if the named file in a hooks directory is the overlay of
+// another hooks file in it
+func isOverlayFile(name string, names map[string]bool) bool {
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+	dot := strings.LastIndex(stem, ".")
+
+	if dot <= 0 {
+		return false
+	}
+
+	for ext := range hooksFileExtensions {
+		if names[stem[:dot]+ext] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadOverlay reads the overlay file, an object mapping hook ids, or ids with the local
+// index in brackets, to merge patches of the hooks
+func loadOverlay(path string) (*document.Object, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := detectFormat("", path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	converted, err := codecs[format].toJSON(parseableHooksData(data, format))
+
+	if err != nil {
+		return nil, fmt.Errorf("could not load overlay %s: %s", path, err)
+	}
+
+	overlay := document.New()
+
+	if err := json.Unmarshal(converted, overlay); err != nil {
+		return nil, fmt.Errorf("overlay %s must map hook ids to patches: %s", path, err)
+	}
+
+	return overlay, nil
+}
+
+// applyOverlay applies the patches of the overlay to the hook documents, a null patch
+// removes the hook and a patch for an unknown id adds a new hook
+func applyOverlay(documents []*document.Object, overlay *document.Object, path string) ([]*document.Object, error) {
+	ids := make([]string, len(documents))
+
+	for i, d := range documents {
+		var h struct {
+			ID string `json:"id"`
+		}
+
+		d.Decode(&h)
+		ids[i] = h.ID
+	}
+
+	removed := make(map[int]bool)
+
+	for _, key := range overlay.Keys {
+		id, idx, indexed := key, 0, false
+
+		if match := overlayKeyPattern.FindStringSubmatch(key); match != nil {
+			id, indexed = match[1], true
+			idx, _ = strconv.Atoi(match[2])
+		}
+
+		var matching []int
+
+		for i := range ids {
+			if ids[i] == id {
+				matching = append(matching, i)
+			}
+		}
+
+		position := -1
+
+		switch {
+		case indexed && idx < len(matching):
+			position = matching[idx]
+		case indexed:
+			return nil, fmt.Errorf("%s: there is no hook %s", path, key)
+		case len(matching) == 1:
+			position = matching[0]
+		case len(matching) > 1:
+			return nil, fmt.Errorf("%s: there are %d hook(s) matching %s\nuse %s[index] to specify the one you want to patch", path, len(matching), id, id)
+		}
+
+		raw := overlay.Values[key]
+
+		if strings.TrimSpace(string(raw)) == "null" {
+			if position < 0 {
+				return nil, fmt.Errorf("%s: there is no hook %s to remove", path, key)
+			}
+
+			removed[position] = true
+			continue
+		}
+
+		patch := document.New()
+
+		if err := json.Unmarshal(raw, patch); err != nil {
+			return nil, fmt.Errorf("%s: patch of %s must be an object or null", path, key)
+		}
+
+		if position < 0 {
+			encodedID, _ := json.Marshal(id)
+
+			added := document.New()
+			added.Set("id", encodedID)
+
+			if err := added.MergePatch(patch); err != nil {
+				return nil, err
+			}
+
+			documents = append(documents, added)
+			ids = append(ids, id)
+			continue
+		}
+
+		if err := documents[position].MergePatch(patch); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []*document.Object
+
+	for i, d := range documents {
+		if !removed[i] {
+			result = append(result, d)
+		}
+	}
+
+	return result, nil
+}
+
+// environmentDocuments returns the rendered hook documents with the overlays of the given
+// environments applied in order and the variables filled in
+func environmentDocuments(envs []string) ([]*document.Object, error) {
+	documents, err := renderDocuments()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, env := range envs {
+		paths, err := overlayPaths(env)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range paths {
+			overlay, err := loadOverlay(path)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if documents, err = applyOverlay(documents, overlay, path); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !substitutionEnabled() {
+		return documents, nil
+	}
+
+	v, err := loadVariables()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := v.substituteDocuments(documents); err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+// indexedDocuments keys the hook documents by id and local index, keeping their order
+func indexedDocuments(documents []*document.Object) (map[string]*document.Object, []hookChange) {
+	indexed := make(map[string]*document.Object)
+	counts := make(map[string]int)
+	var keys []hookChange
+
+	for _, d := range documents {
+		var h struct {
+			ID string `json:"id"`
+		}
+
+		d.Decode(&h)
+
+		key := hookChange{ID: h.ID, Index: counts[h.ID]}
+		indexed[fmt.Sprintf("%s[%d]", key.ID, key.Index)] = d
+		keys = append(keys, key)
+		counts[h.ID]++
+	}
+
+	return indexed, keys
+}
+
+func compactJSON(value json.RawMessage) string {
+	var v interface{}
+
+	if err := json.Unmarshal(value, &v); err != nil {
+		return string(value)
+	}
+
+	encoded, _ := encodeJSON(v, "", "")
+
+	return string(restoreTemplateStrings(encoded))
+}
+
+// hookDifference is a hook that differs between two environments
+type hookDifference struct {
+	ID         string               `json:"id"`
+	Index      int                  `json:"index"`
+	Status     string               `json:"status"`
+	Properties []propertyDifference `json:"properties,omitempty"`
+}
+
+// propertyDifference is a property of a hook that differs between two environments, nested
+// properties are joined with dots
+type propertyDifference struct {
+	Property string          `json:"property"`
+	Left     json.RawMessage `json:"left,omitempty"`
+	Right    json.RawMessage `json:"right,omitempty"`
+}
+
+// environmentDifferences is the diff output
+type environmentDifferences struct {
+	Left  string           `json:"left"`
+	Right string           `json:"right"`
+	Hooks []hookDifference `json:"hooks"`
+}
+
+// diffObjects returns the properties that differ between the two objects, nested objects
+// are compared property by property
+func diffObjects(prefix string, l, r *document.Object) []propertyDifference {
+	var differences []propertyDifference
+	seen := make(map[string]bool)
+
+	for _, property := range append(append([]string(nil), l.Keys...), r.Keys...) {
+		if seen[property] {
+			continue
+		}
+
+		seen[property] = true
+
+		lv, lok := l.Get(property)
+		rv, rok := r.Get(property)
+
+		if lok && rok && compactJSON(lv) == compactJSON(rv) {
+			continue
+		}
+
+		lo, ro := document.New(), document.New()
+
+		if lok && rok && json.Unmarshal(lv, lo) == nil && json.Unmarshal(rv, ro) == nil {
+			differences = append(differences, diffObjects(prefix+property+".", lo, ro)...)
+			continue
+		}
+
+		differences = append(differences, propertyDifference{Property: prefix + property, Left: lv, Right: rv})
+	}
+
+	return differences
+}
+
+func (d propertyDifference) String() string {
+	l, r := "(unset)", "(unset)"
+
+	if d.Left != nil {
+		l = compactJSON(d.Left)
+	}
+
+	if d.Right != nil {
+		r = compactJSON(d.Right)
+	}
+
+	return fmt.Sprintf("    %s: %s => %s", d.Property, l, r)
+}
+
+func diffEnvironments(c *cli.Context) {
+	envs := c.StringSlice("env")
+
+	if len(envs) == 0 || len(envs) > 2 {
+		log.Fatalln("error: diff needs one --env to compare with the base hooks, or two --env to compare with each other")
+	}
+
+	if err := loadHooks(c); err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	leftName, leftEnvs := "base", []string(nil)
+
+	if len(envs) == 2 {
+		leftName, leftEnvs = envs[0], envs[:1]
+	}
+
+	rightName := envs[len(envs)-1]
+
+	left, err := environmentDocuments(leftEnvs)
+
+	if err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	right, err := environmentDocuments([]string{rightName})
+
+	if err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	leftIndexed, leftKeys := indexedDocuments(left)
+	rightIndexed, rightKeys := indexedDocuments(right)
+
+	result := environmentDifferences{Left: leftName, Right: rightName, Hooks: make([]hookDifference, 0)}
+
+	for _, key := range leftKeys {
+		name := fmt.Sprintf("%s[%d]", key.ID, key.Index)
+		l, r := leftIndexed[name], rightIndexed[name]
+
+		if r == nil {
+			result.Hooks = append(result.Hooks, hookDifference{ID: key.ID, Index: key.Index, Status: "removed"})
+		} else if properties := diffObjects("", l, r); len(properties) > 0 {
+			result.Hooks = append(result.Hooks, hookDifference{ID: key.ID, Index: key.Index, Status: "changed", Properties: properties})
+		}
+	}
+
+	for _, key := range rightKeys {
+		if leftIndexed[fmt.Sprintf("%s[%d]", key.ID, key.Index)] == nil {
+			result.Hooks = append(result.Hooks, hookDifference{ID: key.ID, Index: key.Index, Status: "added"})
+		}
+	}
+
+	if format := outputFormat(c); format != outputText {
+		writeOutput(format, result)
+	} else {
+		for _, difference := range result.Hooks {
+			switch difference.Status {
+			case "removed":
+				log.Printf("- %s [%d] only in %s\n", difference.ID, difference.Index, leftName)
+			case "added":
+				log.Printf("+ %s [%d] only in %s\n", difference.ID, difference.Index, rightName)
+			default:
+				log.Printf("~ %s [%d]\n", difference.ID, difference.Index)
+
+				for _, property := range difference.Properties {
+					log.Println(property)
+				}
+			}
+		}
+
+		if len(result.Hooks) == 0 {
+			log.Printf("no differences between %s and %s\n", leftName, rightName)
+		} else {
+			log.Printf("%d hook(s) differ between %s and %s\n", len(result.Hooks), leftName, rightName)
+		}
+	}
+
+	if len(result.Hooks) > 0 {
+		os.Exit(1)
+	}
+}
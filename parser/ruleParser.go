@@ -297,31 +297,6 @@ func (parser *RuleParser) parseRule(depth int) (*hook.Rules, error) {
 	return &rule, err
 }
 
-func printRules(r *[]hook.Rules, indent int) {
-	for _, rule := range *r {
-		printRule(&rule, indent)
-	}
-}
-
-func printRule(r *hook.Rules, indent int) {
-	for i := 0; i < indent; i++ {
-		fmt.Printf(" ")
-	}
-	switch {
-	case r.And != nil:
-		fmt.Printf("And:\n")
-		printRules((*[]hook.Rules)(r.And), indent+1)
-	case r.Or != nil:
-		fmt.Printf("Or:\n")
-		printRules((*[]hook.Rules)(r.Or), indent+1)
-	case r.Not != nil:
-		fmt.Printf("Not:\n")
-		printRule((*hook.Rules)(r.Not), indent+1)
-	case r.Match != nil:
-		fmt.Printf("Match: %+v\n", r.Match)
-	}
-}
-
 // Parse performs lexical analysis of the input string and generates rules based on the lexer output
 func (parser *RuleParser) Parse() error {
 	if errors := parser.Lexer.Lex(); len(errors) > 0 {
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/adnanh/hookman/document"
+	"github.com/adnanh/hookman/json5"
+	"gopkg.in/yaml.v3"
+)
+
+// patchEntry is a hook to be written to the patched hooks file
+type patchEntry struct {
+	index    int
+	document *document.Object
+	changed  bool
+}
+
+// encodeJSON returns the indented JSON encoding of v without escaping HTML characters,
+// every line but the first one starts with the given prefix
+func encodeJSON(v interface{}, prefix, indent string) ([]byte, error) {
+	var out bytes.Buffer
+
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent(prefix, indent)
+
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(out.Bytes(), []byte("\n")), nil
+}
+
+// lineIndentation returns the whitespace preceding pos on its line, or an empty string if
+// there is something else before pos
+func lineIndentation(data []byte, pos int) string {
+	start := bytes.LastIndexByte(data[:pos], '\n') + 1
+	indentation := string(data[start:pos])
+
+	if strings.TrimLeft(indentation, " \t") != "" {
+		return ""
+	}
+
+	return indentation
+}
+
+// lineEnding returns the line ending used in the given document
+func lineEnding(data []byte) string {
+	if bytes.Contains(data, []byte("\r\n")) {
+		return "\r\n"
+	}
+
+	return "\n"
+}
+
+// indentationUnit guesses the indentation used inside the given JSON element
+func indentationUnit(data []byte, span document.Span, elementIndentation string) string {
+	lines := strings.Split(string(data[span.Start:span.End]), "\n")
+
+	if len(lines) > 1 {
+		line := lines[1]
+		unit := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+		if strings.HasPrefix(unit, elementIndentation) && len(unit) > len(elementIndentation) {
+			return unit[len(elementIndentation):]
+		}
+	}
+
+	return "  "
+}
+
+// patchJSON rewrites only the changed, added and removed elements of the original JSON array
+func patchJSON(original []byte, entries []patchEntry) ([]byte, bool) {
+	layout, err := document.Layout(original)
+
+	if err != nil {
+		return nil, false
+	}
+
+	return patchArray(original, layout, entries)
+}
+
+// patchJSON5 rewrites only the changed, added and removed elements of the original JSON5
+// array, the rewritten elements are written as plain JSON and lose their comments
+func patchJSON5(original []byte, entries []patchEntry) ([]byte, bool) {
+	layout, err := json5.Layout(original)
+
+	if err != nil {
+		return nil, false
+	}
+
+	return patchArray(original, layout, entries)
+}
+
+// patchArray splices the changed, added and removed elements into the original array
+// with the given layout, unchanged elements and the text between them are kept as is
+func patchArray(original []byte, layout *document.ArrayLayout, entries []patchEntry) ([]byte, bool) {
+	if len(layout.Elements) == 0 {
+		return nil, false
+	}
+
+	first := layout.Elements[0]
+	elementIndentation := lineIndentation(original, first.Start)
+	unit := indentationUnit(original, first, elementIndentation)
+
+	newline := lineEnding(original)
+	separator := "," + newline + elementIndentation
+
+	// the text between the first two elements may hold comments belonging to the second one
+	if len(layout.Elements) > 1 {
+		if between := string(original[first.End:layout.Elements[1].Start]); !strings.Contains(between, "/") {
+			separator = between
+		}
+	}
+
+	if len(entries) == 0 {
+		var out bytes.Buffer
+
+		out.Write(original[:layout.Open])
+		out.WriteString("[]")
+		out.Write(original[layout.Close+1:])
+
+		return out.Bytes(), true
+	}
+
+	var out bytes.Buffer
+
+	out.Write(original[:first.Start])
+
+	// the text between two elements, like the comments of the second element in JSON5,
+	// stays with the second element
+	for i, entry := range entries {
+		if i > 0 {
+			if entry.index > 0 {
+				out.Write(original[layout.Elements[entry.index-1].End:layout.Elements[entry.index].Start])
+			} else {
+				out.WriteString(separator)
+			}
+		}
+
+		if entry.index >= 0 && !entry.changed {
+			span := layout.Elements[entry.index]
+			out.Write(original[span.Start:span.End])
+			continue
+		}
+
+		encoded, err := encodeJSON(entry.document, elementIndentation, unit)
+
+		if err != nil {
+			return nil, false
+		}
+
+		out.Write(bytes.Replace(encoded, []byte("\n"), []byte(newline), -1))
+	}
+
+	out.Write(original[layout.Elements[len(layout.Elements)-1].End:])
+
+	return out.Bytes(), true
+}
+
+// yamlItemLines returns the first and the last line (zero based, inclusive) of every item of
+// the top level block sequence, with the column of the item dash
+func yamlItemLines(lines []string, root *yaml.Node) ([][2]int, int, bool) {
+	if root.Kind != yaml.SequenceNode || root.Style&yaml.FlowStyle != 0 || len(root.Content) == 0 {
+		return nil, 0, false
+	}
+
+	column := -1
+	var items [][2]int
+
+	for _, item := range root.Content {
+		line := item.Line - 1
+
+		for line >= 0 && strings.TrimSpace(lines[line]) == "" {
+			line--
+		}
+
+		if line < 0 {
+			return nil, 0, false
+		}
+
+		dash := strings.Index(lines[line], "-")
+
+		if dash < 0 || strings.TrimSpace(lines[line][:dash]) != "" || (column >= 0 && dash != column) {
+			return nil, 0, false
+		}
+
+		column = dash
+		items = append(items, [2]int{line, line})
+	}
+
+	for i := range items {
+		end := items[i][0]
+
+		for next := end + 1; next < len(lines); next++ {
+			trimmed := strings.TrimSpace(lines[next])
+
+			if trimmed == "" {
+				continue
+			}
+
+			if len(lines[next])-len(strings.TrimLeft(lines[next], " ")) <= column {
+				break
+			}
+
+			end = next
+		}
+
+		items[i][1] = end
+	}
+
+	return items, column, true
+}
+
+// patchYAML rewrites only the changed, added and removed items of the original YAML sequence,
+// comments inside the rewritten items are lost
+func patchYAML(original []byte, entries []patchEntry) ([]byte, bool) {
+	var node yaml.Node
+
+	if err := yaml.Unmarshal(original, &node); err != nil || len(node.Content) == 0 {
+		return nil, false
+	}
+
+	lines := strings.SplitAfter(string(original), "\n")
+
+	items, column, ok := yamlItemLines(lines, node.Content[0])
+
+	if !ok || len(entries) == 0 {
+		return nil, false
+	}
+
+	newline := lineEnding(original)
+
+	render := func(o *document.Object) (string, bool) {
+		data, err := json.Marshal([]*document.Object{o})
+
+		if err == nil {
+			data, err = jsonToYAML(data)
+		}
+
+		if err != nil {
+			return "", false
+		}
+
+		indentation := strings.Repeat(" ", column)
+		rendered := strings.SplitAfter(strings.TrimSuffix(string(data), "\n"), "\n")
+
+		return strings.Replace(indentation+strings.Join(rendered, indentation)+"\n", "\n", newline, -1), true
+	}
+
+	// lines between two items, like blank lines and the head comments of the second
+	// item, stay with the second item
+	gap := func(idx int) string {
+		if idx == 0 {
+			return ""
+		}
+
+		return strings.Join(lines[items[idx-1][1]+1:items[idx][0]], "")
+	}
+
+	separator := ""
+
+	if len(items) > 1 {
+		for _, line := range strings.SplitAfter(gap(1), "\n") {
+			if strings.TrimSpace(line) == "" {
+				separator += line
+			}
+		}
+	}
+
+	var out strings.Builder
+
+	out.WriteString(strings.Join(lines[:items[0][0]], ""))
+
+	for i, entry := range entries {
+		if entry.index < 0 {
+			if i > 0 {
+				out.WriteString(separator)
+			}
+		} else {
+			out.WriteString(gap(entry.index))
+		}
+
+		if entry.index >= 0 && !entry.changed {
+			out.WriteString(strings.Join(lines[items[entry.index][0]:items[entry.index][1]+1], ""))
+
+			if !strings.HasSuffix(lines[items[entry.index][1]], "\n") {
+				out.WriteString(newline)
+			}
+
+			continue
+		}
+
+		rendered, ok := render(entry.document)
+
+		if !ok {
+			return nil, false
+		}
+
+		out.WriteString(rendered)
+	}
+
+	out.WriteString(strings.Join(lines[items[len(items)-1][1]+1:], ""))
+
+	return []byte(out.String()), true
+}
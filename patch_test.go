@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/adnanh/hookman/document"
+	"github.com/adnanh/hookman/json5"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	patchEdit = "edit"
+	patchAdd  = "add"
+	patchDel  = "delete"
+)
+
+var patchedHook = `{"id":"new","execute-command":"/bin/new","pass-arguments-to-command":[{"source":"payload","name":"ref"}]}`
+
+var patchJSONFiles = []struct {
+	name   string
+	data   string
+	indent string
+}{
+	{"two spaces", "[\n  {\n    \"id\": \"a\",\n    \"execute-command\": \"/bin/a\"\n  },\n  {\n    \"id\": \"b\",\n    \"execute-command\": \"/bin/b\"\n  },\n  {\n    \"id\": \"c\",\n    \"execute-command\": \"/bin/c\"\n  }\n]", "  "},
+	{"four spaces", "[\n    {\n        \"id\": \"a\",\n        \"execute-command\": \"/bin/a\"\n    },\n    {\n        \"id\": \"b\",\n        \"execute-command\": \"/bin/b\"\n    },\n    {\n        \"id\": \"c\",\n        \"execute-command\": \"/bin/c\"\n    }\n]\n", "    "},
+	{"tabs", "[\n\t{\n\t\t\"id\": \"a\",\n\t\t\"execute-command\": \"/bin/a\"\n\t},\n\t{\n\t\t\"id\": \"b\",\n\t\t\"execute-command\": \"/bin/b\"\n\t},\n\t{\n\t\t\"id\": \"c\",\n\t\t\"execute-command\": \"/bin/c\"\n\t}\n]\n", "\t"},
+	{"crlf", "[\r\n  {\r\n    \"id\": \"a\",\r\n    \"execute-command\": \"/bin/a\"\r\n  },\r\n  {\r\n    \"id\": \"b\",\r\n    \"execute-command\": \"/bin/b\"\r\n  },\r\n  {\r\n    \"id\": \"c\",\r\n    \"execute-command\": \"/bin/c\"\r\n  }\r\n]\r\n", "  "},
+	{"odd spacing", "  [ {\"id\": \"a\", \"execute-command\": \"/bin/a\"},\n\n  {\"id\": \"b\", \"execute-command\": \"/bin/b\" } ,\n  {\"id\": \"c\",\"execute-command\":\"/bin/c\"}  ]  \n", "  "},
+}
+
+var patchYAMLFiles = []struct {
+	name string
+	data string
+}{
+	{"plain", "- id: a\n  execute-command: /bin/a\n- id: b\n  execute-command: /bin/b\n- id: c\n  execute-command: /bin/c\n"},
+	{"comments", "# hooks\n\n# first\n- id: a # trailing\n  execute-command: /bin/a\n\n# second\n- id: b\n  # inside\n  execute-command: /bin/b\n\n# third\n- id: c\n  execute-command: /bin/c\n# end\n"},
+	{"crlf", "# hooks\r\n- id: a\r\n  execute-command: /bin/a\r\n- id: b\r\n  execute-command: /bin/b\r\n- id: c\r\n  execute-command: /bin/c\r\n"},
+	{"indented", "  - id: a\n    execute-command: /bin/a\n  - id: b\n    execute-command: /bin/b\n  - id: c\n    execute-command: /bin/c\n"},
+	{"four spaces", "-   id: a\n    execute-command: /bin/a\n-   id: b\n    execute-command: /bin/b\n-   id: c\n    execute-command: /bin/c\n"},
+}
+
+// patchOperations are the edits, additions and deletions of the first, middle and last hook
+var patchOperations = []struct {
+	operation string
+	position  int
+}{
+	{patchEdit, 0}, {patchEdit, 1}, {patchEdit, 2},
+	{patchAdd, 0}, {patchAdd, 1}, {patchAdd, 3},
+	{patchDel, 0}, {patchDel, 1}, {patchDel, 2},
+}
+
+// patchCase returns the entries of the operation and the documents the patched file must hold
+func patchCase(t *testing.T, documents []*document.Object, operation string, position int) ([]patchEntry, []*document.Object) {
+	hook := document.New()
+
+	if err := json.Unmarshal([]byte(patchedHook), hook); err != nil {
+		t.Fatal(err)
+	}
+
+	var entries []patchEntry
+	var expected []*document.Object
+
+	for i, o := range documents {
+		switch {
+		case operation == patchAdd && i == position:
+			entries = append(entries, patchEntry{index: -1, document: hook, changed: true})
+			expected = append(expected, hook)
+		case operation == patchDel && i == position:
+			continue
+		case operation == patchEdit && i == position:
+			entries = append(entries, patchEntry{index: i, document: hook, changed: true})
+			expected = append(expected, hook)
+			continue
+		}
+
+		entries = append(entries, patchEntry{index: i, document: o})
+		expected = append(expected, o)
+	}
+
+	if operation == patchAdd && position == len(documents) {
+		entries = append(entries, patchEntry{index: -1, document: hook, changed: true})
+		expected = append(expected, hook)
+	}
+
+	return entries, expected
+}
+
+// changedRegion returns the bytes of the original file the operation may change, given the
+// spans of the hooks, deletions take one of the separators with them
+func changedRegion(spans []document.Span, operation string, position int) (int, int) {
+	last := len(spans) - 1
+
+	switch {
+	case operation == patchEdit:
+		return spans[position].Start, spans[position].End
+	case operation == patchDel && position == 0:
+		return spans[0].Start, spans[1].Start
+	case operation == patchDel:
+		return spans[position-1].End, spans[position].End
+	case operation == patchAdd && position == 0:
+		return spans[0].Start, spans[0].Start
+	case position > last:
+		return spans[last].End, spans[last].End
+	default:
+		return spans[position-1].End, spans[position].Start
+	}
+}
+
+// checkPatched checks that the patched file differs from the original only in the region
+// and returns the replacement of the region
+func checkPatched(t *testing.T, name string, original, patched []byte, start, end int) []byte {
+	suffix := original[end:]
+
+	if !bytes.HasPrefix(patched, original[:start]) || !bytes.HasSuffix(patched, suffix) || len(patched) < start+len(suffix) {
+		t.Errorf("%s: bytes outside of the changed hook differ\noriginal:\n%q\npatched:\n%q", name, original, patched)
+		return nil
+	}
+
+	return patched[start : len(patched)-len(suffix)]
+}
+
+func checkDocuments(t *testing.T, name string, data []byte, expected []*document.Object) {
+	var got, want []map[string]interface{}
+
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Errorf("%s: patched file does not parse: %s\n%s", name, err, data)
+		return
+	}
+
+	encoded, _ := json.Marshal(expected)
+	json.Unmarshal(encoded, &want)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: got hooks %v, want %v", name, got, want)
+	}
+}
+
+func TestPatchJSON(t *testing.T) {
+	for _, file := range patchJSONFiles {
+		original := []byte(file.data)
+
+		documents, err := document.UnmarshalArray(original)
+
+		if err != nil {
+			t.Fatalf("%s: %s", file.name, err)
+		}
+
+		layout, err := document.Layout(original)
+
+		if err != nil {
+			t.Fatalf("%s: %s", file.name, err)
+		}
+
+		for _, op := range patchOperations {
+			name := file.name + " " + op.operation + " " + string(rune('0'+op.position))
+			entries, expected := patchCase(t, documents, op.operation, op.position)
+
+			patched, ok := patchJSON(original, entries)
+
+			if !ok {
+				t.Errorf("%s: not patched", name)
+				continue
+			}
+
+			checkDocuments(t, name, patched, expected)
+
+			start, end := changedRegion(layout.Elements, op.operation, op.position)
+			replacement := checkPatched(t, name, original, patched, start, end)
+
+			for _, line := range strings.Split(string(replacement), "\n")[1:] {
+				if indentation := line[:len(line)-len(strings.TrimLeft(line, " \t"))]; strings.Trim(indentation, file.indent) != "" {
+					t.Errorf("%s: line %q is not indented with %q", name, line, file.indent)
+				}
+			}
+
+			if strings.Contains(file.data, "\r\n") && strings.Count(string(patched), "\n") != strings.Count(string(patched), "\r\n") {
+				t.Errorf("%s: patched file mixes line endings\n%q", name, patched)
+			}
+		}
+	}
+}
+
+func TestPatchJSON5(t *testing.T) {
+	original := []byte("// hooks\n[\n  // a\n  {id: 'a', 'execute-command': '/bin/a'},\n\n  /* b */\n  {\n    id: \"b\", // inside\n    \"execute-command\": \"/bin/b\"\n  },\n  // c\n  {id: 'c', 'execute-command': '/bin/c',},\n]\n")
+
+	converted, err := json5.ToJSON(original)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	documents, err := document.UnmarshalArray(converted)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	layout, err := json5.Layout(original)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, op := range patchOperations {
+		name := op.operation + " " + string(rune('0'+op.position))
+		entries, expected := patchCase(t, documents, op.operation, op.position)
+
+		patched, ok := patchJSON5(original, entries)
+
+		if !ok {
+			t.Errorf("%s: not patched", name)
+			continue
+		}
+
+		data, err := json5.ToJSON(patched)
+
+		if err != nil {
+			t.Errorf("%s: patched file does not parse: %s\n%s", name, err, patched)
+			continue
+		}
+
+		checkDocuments(t, name, data, expected)
+
+		start, end := changedRegion(layout.Elements, op.operation, op.position)
+		checkPatched(t, name, original, patched, start, end)
+	}
+}
+
+func TestPatchJSONRemoveAll(t *testing.T) {
+	original := []byte("// not json\n")
+
+	if _, ok := patchJSON(original, nil); ok {
+		t.Errorf("patched a file that is not a JSON array")
+	}
+
+	original = []byte("[\n  {\"id\": \"a\"}\n]\n")
+	patched, ok := patchJSON(original, nil)
+
+	if !ok || string(patched) != "[]\n" {
+		t.Errorf("got %q, want %q", patched, "[]\n")
+	}
+}
+
+// yamlSpans returns the spans of the items of the YAML sequence, an item starts with its
+// first line and ends after its last line
+func yamlSpans(t *testing.T, data []byte) []document.Span {
+	var node yaml.Node
+
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	items, _, ok := yamlItemLines(lines, node.Content[0])
+
+	if !ok {
+		t.Fatalf("no block sequence in %q", data)
+	}
+
+	offset := func(line int) int {
+		return len(strings.Join(lines[:line], ""))
+	}
+
+	spans := make([]document.Span, len(items))
+
+	for i, item := range items {
+		spans[i] = document.Span{Start: offset(item[0]), End: offset(item[1] + 1)}
+	}
+
+	return spans
+}
+
+func TestPatchYAML(t *testing.T) {
+	for _, file := range patchYAMLFiles {
+		original := []byte(file.data)
+
+		data, err := yamlToJSON(original)
+
+		if err != nil {
+			t.Fatalf("%s: %s", file.name, err)
+		}
+
+		documents, err := document.UnmarshalArray(data)
+
+		if err != nil {
+			t.Fatalf("%s: %s", file.name, err)
+		}
+
+		spans := yamlSpans(t, original)
+
+		for _, op := range patchOperations {
+			name := file.name + " " + op.operation + " " + string(rune('0'+op.position))
+			entries, expected := patchCase(t, documents, op.operation, op.position)
+
+			patched, ok := patchYAML(original, entries)
+
+			if !ok {
+				t.Errorf("%s: not patched", name)
+				continue
+			}
+
+			data, err := yamlToJSON(patched)
+
+			if err != nil {
+				t.Errorf("%s: patched file does not parse: %s\n%s", name, err, patched)
+				continue
+			}
+
+			checkDocuments(t, name, data, expected)
+
+			// deletions take the lines before the item with them, like its head comments
+			start, end := changedRegion(spans, op.operation, op.position)
+
+			if op.operation == patchDel {
+				start, end = spans[op.position].Start, spans[op.position].End
+
+				if op.position > 0 {
+					start = spans[op.position-1].End
+				}
+			}
+
+			checkPatched(t, name, original, patched, start, end)
+
+			if strings.Contains(file.data, "\r\n") && strings.Count(string(patched), "\n") != strings.Count(string(patched), "\r\n") {
+				t.Errorf("%s: patched file mixes line endings\n%q", name, patched)
+			}
+		}
+	}
+}
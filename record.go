@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adnanh/hookman/fixture"
+	"github.com/codegangsta/cli"
+)
+
+const (
+	redactedValue       = "REDACTED"
+	maxRecordedResponse = 4096
+)
+
+type deliveryRecorder struct {
+	sync.Mutex
+
+	out    *os.File
+	prefix string
+	redact map[string]bool
+}
+
+type recordingResponseWriter struct {
+	http.ResponseWriter
+
+	status   int
+	response bytes.Buffer
+}
+
+func (w *recordingResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingResponseWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if remaining := maxRecordedResponse - w.response.Len(); remaining > 0 {
+		if len(data) < remaining {
+			remaining = len(data)
+		}
+
+		w.response.Write(data[:remaining])
+	}
+
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// newTransparentProxy returns a reverse proxy that forwards requests to upstream without
+// rewriting the Host header or adding X-Forwarded-For
+func newTransparentProxy(upstream *url.URL) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Director: func(r *http.Request) {
+			r.URL.Scheme = upstream.Scheme
+			r.URL.Host = upstream.Host
+			// the escaped path is kept as well, so that encoded segments like %2F are sent on as is
+			r.URL.RawPath = strings.TrimSuffix(upstream.EscapedPath(), "/") + r.URL.EscapedPath()
+			r.URL.Path = strings.TrimSuffix(upstream.Path, "/") + r.URL.Path
+
+			if _, ok := r.Header["X-Forwarded-For"]; !ok {
+				r.Header["X-Forwarded-For"] = nil
+			}
+		},
+	}
+}
+
+func (recorder *deliveryRecorder) ServeHTTP(proxy http.Handler, w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		http.Error(w, "could not read request body", http.StatusBadGateway)
+		log.Printf("error: could not read request body: %s\n", err)
+		return
+	}
+
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	header := make(map[string]string)
+	var redacted []string
+
+	for name, values := range r.Header {
+		if recorder.redact[strings.ToLower(name)] {
+			header[name] = redactedValue
+			redacted = append(redacted, name)
+		} else if len(values) > 0 {
+			// webhook reads only the first value of a repeated header
+			header[name] = values[0]
+		}
+	}
+
+	if r.Host != "" {
+		header["Host"] = r.Host
+	}
+
+	recorded := &recordingResponseWriter{ResponseWriter: w}
+	started := time.Now()
+
+	proxy.ServeHTTP(recorded, r)
+
+	delivery := fixture.NewRequest(r.Method, r.URL.RequestURI(), header, body)
+	delivery.HookID = fixture.HookIDFromPath(r.URL.Path, recorder.prefix)
+	delivery.Recorded = &fixture.Recording{
+		Time:     started.UTC(),
+		Status:   recorded.status,
+		Response: recorded.response.String(),
+		Redacted: redacted,
+	}
+
+	recorder.Lock()
+	err = fixture.WriteJSONLine(recorder.out, delivery)
+	recorder.Unlock()
+
+	if err != nil {
+		log.Printf("error: could not record delivery: %s\n", err)
+		return
+	}
+
+	if delivery.HookID == "" {
+		log.Printf(" * %s %s => %d (not a hook)\n", r.Method, r.URL.Path, recorded.status)
+	} else {
+		log.Printf(" * %s %s => %d\n", r.Method, delivery.HookID, recorded.status)
+	}
+}
+
+func recordDeliveries(c *cli.Context) {
+	if !c.IsSet("upstream") {
+		log.Fatalln("error: you must supply --upstream url of the running webhook")
+	}
+
+	upstream, err := url.Parse(c.String("upstream"))
+
+	if err != nil || upstream.Scheme == "" || upstream.Host == "" {
+		log.Fatalf("error: invalid upstream url %s\n", c.String("upstream"))
+	}
+
+	out, err := os.OpenFile(c.String("out"), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+
+	if err != nil {
+		log.Fatalf("error: could not open deliveries file: %s\n", err)
+	}
+
+	defer out.Close()
+
+	recorder := &deliveryRecorder{out: out, prefix: c.String("prefix"), redact: make(map[string]bool)}
+
+	for _, name := range c.StringSlice("redact") {
+		recorder.redact[strings.ToLower(name)] = true
+	}
+
+	proxy := newTransparentProxy(upstream)
+
+	log.Printf("recording deliveries to %s, forwarding %s to %s\n", c.String("out"), c.String("listen"), upstream)
+
+	err = http.ListenAndServe(c.String("listen"), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder.ServeHTTP(proxy, w, r)
+	}))
+
+	log.Fatalf("error: could not start recording proxy: %s\n", err)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/adnanh/hookman/evaluator"
+	"github.com/adnanh/hookman/fixture"
+	"github.com/codegangsta/cli"
+)
+
+type deliveryOutcome int
+
+const (
+	outcomeUnknown deliveryOutcome = iota
+	outcomeNotFound
+	outcomeRejected
+	outcomeMatched
+)
+
+const rulesNotSatisfiedResponse = "Hook rules were not satisfied."
+
+// replayedDelivery is a delivery in the replay output
+type replayedDelivery struct {
+	Delivery int        `json:"delivery"`
+	HookID   string     `json:"hook"`
+	Time     *time.Time `json:"time,omitempty"`
+	Recorded string     `json:"recorded"`
+	Current  string     `json:"current"`
+	Status   string     `json:"status"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// replayResults is the replay output
+type replayResults struct {
+	Deliveries []replayedDelivery `json:"deliveries"`
+	Replayed   int                `json:"replayed"`
+	Changed    int                `json:"changed"`
+	Skipped    int                `json:"skipped"`
+}
+
+func (o deliveryOutcome) String() string {
+	switch o {
+	case outcomeNotFound:
+		return "hook not found"
+	case outcomeRejected:
+		return "rejected"
+	case outcomeMatched:
+		return "matched"
+	default:
+		return "unknown"
+	}
+}
+
+// recordedOutcome derives the outcome from the webhook response captured by the record command
+func recordedOutcome(r *fixture.Request) deliveryOutcome {
+	switch {
+	case r.Recorded == nil:
+		return outcomeUnknown
+	case r.Recorded.Status == 404:
+		return outcomeNotFound
+	case strings.Contains(r.Recorded.Response, rulesNotSatisfiedResponse):
+		return outcomeRejected
+	case r.Recorded.Status >= 200 && r.Recorded.Status < 300:
+		return outcomeMatched
+	default:
+		return outcomeUnknown
+	}
+}
+
+// currentOutcome evaluates the request against the first hook with the matching id,
+// the same one webhook would serve
+func currentOutcome(r *fixture.Request) (deliveryOutcome, error) {
+	hooksSlice, ok := hooksMap[r.HookID]
+
+	if !ok {
+		return outcomeNotFound, nil
+	}
+
+	matched, err := evaluator.Evaluate(hooksSlice[0].TriggerRule, r)
+
+	if err != nil {
+		return outcomeUnknown, err
+	}
+
+	if matched {
+		return outcomeMatched, nil
+	}
+
+	return outcomeRejected, nil
+}
+
+func replayDeliveries(c *cli.Context) {
+	if err := loadHooks(c); err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	if len(c.Args()) == 0 {
+		log.Fatalln("error: you must supply the deliveries file")
+	}
+
+	deliveries, err := fixture.LoadFileLenient(c.Args()[0])
+
+	if err != nil {
+		log.Fatalf("error: could not load deliveries: %s\n", err)
+	}
+
+	hookID := c.String("hook")
+
+	if len(c.StringSlice("set")) > 0 {
+		if hookID == "" {
+			log.Fatalln("error: --set requires --hook to specify the hook being edited")
+		}
+
+		h, err := findOneHook(hookID, c.IsSet("idx"), c.Int("idx"))
+
+		if err != nil {
+			log.Fatalf("error: %s\n", err)
+		}
+
+		if err := setHookProperties(h, c.StringSlice("set")); err != nil {
+			log.Fatalf("error: cannot set property: %s\n", err)
+		}
+	}
+
+	format := outputFormat(c)
+	results := replayResults{Deliveries: make([]replayedDelivery, 0)}
+
+	for idx, delivery := range deliveries {
+		if delivery.HookID == "" || (hookID != "" && delivery.HookID != hookID) {
+			continue
+		}
+
+		results.Replayed++
+
+		before := recordedOutcome(delivery)
+		after, err := outcomeUnknown, delivery.Invalid
+
+		if err == nil {
+			after, err = currentOutcome(delivery)
+		}
+
+		result := replayedDelivery{Delivery: idx + 1, HookID: delivery.HookID, Recorded: before.String(), Current: after.String()}
+		when := fmt.Sprintf("#%d", idx+1)
+
+		if delivery.Recorded != nil {
+			result.Time = &delivery.Recorded.Time
+			when = fmt.Sprintf("#%d at %s", idx+1, delivery.Recorded.Time.Format("2006-01-02 15:04:05"))
+		}
+
+		if delivery.PayloadError != nil && format == outputText && c.Bool("verbose") {
+			log.Printf("   %s %s: evaluated with an empty payload: %s\n", delivery.HookID, when, delivery.PayloadError)
+		}
+
+		switch {
+		case delivery.Invalid != nil:
+			results.Skipped++
+			result.Status, result.Error = "skipped", err.Error()
+
+			if format == outputText {
+				log.Printf(" ? %s %s: skipped: %s\n", delivery.HookID, when, err)
+			}
+		case err != nil:
+			results.Skipped++
+			result.Status, result.Error = "skipped", err.Error()
+
+			if format == outputText {
+				log.Printf(" ? %s %s: cannot evaluate: %s\n", delivery.HookID, when, err)
+			}
+		case before == outcomeUnknown:
+			results.Skipped++
+			result.Status = "skipped"
+
+			if format == outputText && c.Bool("verbose") {
+				log.Printf(" ? %s %s: no recorded outcome, would be %s now\n", delivery.HookID, when, after)
+			}
+		case before != after:
+			results.Changed++
+			result.Status = "changed"
+
+			if format == outputText {
+				log.Printf(" ! %s %s: %s when recorded, would be %s now\n", delivery.HookID, when, before, after)
+			}
+		default:
+			result.Status = "unchanged"
+
+			if format == outputText && c.Bool("verbose") {
+				log.Printf("   %s %s: %s\n", delivery.HookID, when, after)
+			}
+		}
+
+		results.Deliveries = append(results.Deliveries, result)
+	}
+
+	if format == outputText {
+		log.Printf("total %d of %d replayed deliveries would match differently (%d could not be compared)\n", results.Changed, results.Replayed, results.Skipped)
+	} else {
+		writeOutput(format, results)
+	}
+
+	if results.Changed > 0 {
+		os.Exit(1)
+	}
+}
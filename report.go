@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/adnanh/webhook/hook"
+	"github.com/codegangsta/cli"
+)
+
+const maskedValue = "********"
+
+// reportHook is what the list --format template is executed with, the hook fields along
+// with the file the hook came from and its indexes
+type reportHook struct {
+	hook.Hook
+	File     string
+	Index    int
+	Position int
+}
+
+// reportFuncs are the helper functions available to the list --format templates
+var reportFuncs = template.FuncMap{
+	"rule": func(r *hook.Rules) string {
+		if r == nil {
+			return ""
+		}
+
+		return fmt.Sprintf("%s", (*Rules)(r))
+	},
+	"args": argumentsToString,
+	"env":  environmentToString,
+	"mask": maskValue,
+	"json": func(v interface{}) (string, error) {
+		data, err := encodeJSON(v, "", "")
+
+		return string(restoreTemplateStrings(data)), err
+	},
+	"expanded": func(h hook.Hook) string {
+		return Hook(h).String()
+	},
+	"compact": func(h hook.Hook, idx int) string {
+		return fmt.Sprintf(CompactHook(h).String(), idx)
+	},
+}
+
+// maskValue hides secrets, strings are replaced as a whole and rules are rendered with the
+// matched values and the signature secrets replaced
+func maskValue(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case string:
+		if value == "" {
+			return "", nil
+		}
+
+		return maskedValue, nil
+
+	case *hook.Rules:
+		if value == nil {
+			return "", nil
+		}
+
+		data, err := json.Marshal(value)
+
+		if err != nil {
+			return "", err
+		}
+
+		var masked hook.Rules
+
+		if err := json.Unmarshal(data, &masked); err != nil {
+			return "", err
+		}
+
+		maskRules(&masked)
+
+		return fmt.Sprintf("%s", (*Rules)(&masked)), nil
+
+	default:
+		return "", fmt.Errorf("mask expects a string or a rule, got %T", v)
+	}
+}
+
+func maskRules(r *hook.Rules) {
+	switch {
+	case r.And != nil:
+		for i := range *r.And {
+			maskRules(&(*r.And)[i])
+		}
+	case r.Or != nil:
+		for i := range *r.Or {
+			maskRules(&(*r.Or)[i])
+		}
+	case r.Not != nil:
+		maskRules((*hook.Rules)(r.Not))
+	case r.Match != nil:
+		if r.Match.Value != "" {
+			r.Match.Value = maskedValue
+		}
+
+		if r.Match.Secret != "" {
+			r.Match.Secret = maskedValue
+		}
+	}
+}
+
+// writeHooksReport executes the template once for every hook, each followed by a newline
+func writeHooksReport(c *cli.Context, format string) {
+	format = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(format)
+
+	t, err := template.New("format").Funcs(reportFuncs).Parse(format)
+
+	if err != nil {
+		log.Fatalf("error: invalid --format template: %s\n", err)
+	}
+
+	var out bytes.Buffer
+
+	for _, h := range selectedHooks(c) {
+		report := reportHook{Hook: *h, File: hookSources[hookIndex(h)].file.path, Index: localIndex(h), Position: hookSources[hookIndex(h)].index}
+
+		if err := t.Execute(&out, report); err != nil {
+			log.Fatalf("error: could not execute --format template: %s\n", err)
+		}
+
+		out.WriteString("\n")
+	}
+
+	os.Stdout.Write(restoreTemplateActions(out.Bytes()))
+}
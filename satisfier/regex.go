@@ -0,0 +1,104 @@
+package satisfier
+
+import (
+	"math/rand"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+const maxGeneratedRepeat = 3
+
+// generateString returns a string matching the given regular expression, attempt 0
+// returns the simplest string while the other attempts make random choices
+func generateString(re *syntax.Regexp, attempt int) string {
+	var b strings.Builder
+
+	random := rand.New(rand.NewSource(int64(attempt)))
+
+	writeRegexp(&b, re, attempt == 0, random)
+
+	return b.String()
+}
+
+func writeRegexp(b *strings.Builder, re *syntax.Regexp, simplest bool, random *rand.Rand) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		b.WriteRune(pickRune(re.Rune, simplest, random))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		if simplest {
+			b.WriteRune('x')
+		} else {
+			b.WriteRune(rune('a' + random.Intn(26)))
+		}
+	case syntax.OpCapture:
+		writeRegexp(b, re.Sub[0], simplest, random)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			writeRegexp(b, sub, simplest, random)
+		}
+	case syntax.OpAlternate:
+		if simplest {
+			writeRegexp(b, re.Sub[0], simplest, random)
+		} else {
+			writeRegexp(b, re.Sub[random.Intn(len(re.Sub))], simplest, random)
+		}
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := re.Min, re.Max
+
+		switch re.Op {
+		case syntax.OpStar:
+			min, max = 0, -1
+		case syntax.OpPlus:
+			min, max = 1, -1
+		case syntax.OpQuest:
+			min, max = 0, 1
+		}
+
+		if max < 0 || max > min+maxGeneratedRepeat {
+			max = min + maxGeneratedRepeat
+		}
+
+		count := min
+
+		if !simplest {
+			count += random.Intn(max - min + 1)
+		}
+
+		for i := 0; i < count; i++ {
+			writeRegexp(b, re.Sub[0], simplest, random)
+		}
+	}
+}
+
+// pickRune returns a rune from the given class ranges, preferring printable ASCII
+func pickRune(ranges []rune, simplest bool, random *rand.Rand) rune {
+	var candidates []rune
+
+	for i := 0; i+1 < len(ranges); i += 2 {
+		for r := ranges[i]; r <= ranges[i+1] && r < unicode.MaxASCII; r++ {
+			if unicode.IsPrint(r) {
+				candidates = append(candidates, r)
+			}
+		}
+	}
+
+	switch {
+	case len(candidates) > 0 && simplest:
+		for _, r := range candidates {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+		}
+
+		return candidates[0]
+	case len(candidates) > 0:
+		return candidates[random.Intn(len(candidates))]
+	case len(ranges) > 0:
+		return ranges[0]
+	}
+
+	return 'x'
+}
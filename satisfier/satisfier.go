@@ -0,0 +1,408 @@
+package satisfier
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adnanh/hookman/evaluator"
+	"github.com/adnanh/hookman/fixture"
+	"github.com/adnanh/webhook/hook"
+)
+
+const (
+	maxConjunctions     = 4096
+	maxGenerateAttempts = 64
+	sha1SignaturePrefix = "sha1="
+)
+
+var negatedCandidates = []string{"hookman", "x", "0", "-"}
+
+// Solution holds the parameter values of a request that satisfies a rule
+type Solution struct {
+	Header  map[string]string
+	Query   map[string]string
+	Payload map[string]interface{}
+	Body    []byte
+}
+
+type literal struct {
+	match   *hook.MatchRule
+	negated bool
+}
+
+type conjunction []literal
+
+// Satisfy returns the parameter values of a request satisfying the given rule, values
+// are set on top of the given base payload, or an error if the rule cannot be satisfied
+func Satisfy(r *hook.Rules, payload map[string]interface{}) (*Solution, error) {
+	conjunctions, err := disjunctiveNormalForm(r, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if r == nil {
+		conjunctions = []conjunction{{}}
+	}
+
+	reasons := make(map[string]bool)
+
+	for _, c := range conjunctions {
+		solution, err := solve(r, c, payload)
+
+		if err == nil {
+			return solution, nil
+		}
+
+		reasons[err.Error()] = true
+	}
+
+	var reasonsSlice []string
+
+	for reason := range reasons {
+		reasonsSlice = append(reasonsSlice, reason)
+	}
+
+	sort.Strings(reasonsSlice)
+
+	return nil, fmt.Errorf("rule cannot be satisfied:\n\t%s", strings.Join(reasonsSlice, "\n\t"))
+}
+
+// SetParameterValue sets the value at the given path in the node, creating the
+// intermediate objects and arrays as needed, and returns the updated node
+func SetParameterValue(node interface{}, keys []string, value interface{}) interface{} {
+	if len(keys) == 0 {
+		return value
+	}
+
+	if idx, err := strconv.Atoi(keys[0]); err == nil && idx >= 0 {
+		if array, ok := node.([]interface{}); ok || node == nil {
+			for len(array) <= idx {
+				array = append(array, nil)
+			}
+
+			array[idx] = SetParameterValue(array[idx], keys[1:], value)
+
+			return array
+		}
+	}
+
+	object, ok := node.(map[string]interface{})
+
+	if !ok {
+		object = make(map[string]interface{})
+	}
+
+	object[keys[0]] = SetParameterValue(object[keys[0]], keys[1:], value)
+
+	return object
+}
+
+// disjunctiveNormalForm returns the rule as a list of alternative conjunctions of
+// possibly negated match rules, earlier alternatives come first
+func disjunctiveNormalForm(r *hook.Rules, negated bool) ([]conjunction, error) {
+	switch {
+	case r == nil:
+		return nil, nil
+	case r.Match != nil:
+		return []conjunction{{literal{match: r.Match, negated: negated}}}, nil
+	case r.Not != nil:
+		return disjunctiveNormalForm((*hook.Rules)(r.Not), !negated)
+	case r.And != nil && !negated, r.Or != nil && negated:
+		rules := (*[]hook.Rules)(r.And)
+
+		if r.Or != nil {
+			rules = (*[]hook.Rules)(r.Or)
+		}
+
+		result := []conjunction{{}}
+
+		for i := range *rules {
+			alternatives, err := disjunctiveNormalForm(&(*rules)[i], negated)
+
+			if err != nil {
+				return nil, err
+			}
+
+			var product []conjunction
+
+			for _, left := range result {
+				for _, right := range alternatives {
+					product = append(product, append(append(conjunction{}, left...), right...))
+				}
+			}
+
+			if len(product) > maxConjunctions {
+				return nil, fmt.Errorf("rule is too complex to be solved")
+			}
+
+			result = product
+		}
+
+		return result, nil
+	case r.Or != nil, r.And != nil:
+		rules := (*[]hook.Rules)(r.Or)
+
+		if r.And != nil {
+			rules = (*[]hook.Rules)(r.And)
+		}
+
+		var result []conjunction
+
+		for i := range *rules {
+			alternatives, err := disjunctiveNormalForm(&(*rules)[i], negated)
+
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, alternatives...)
+		}
+
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("invalid rule")
+}
+
+func parameterKey(arg hook.Argument) string {
+	if arg.Source == hook.SourceHeader {
+		return arg.Source + "." + textproto.CanonicalMIMEHeaderKey(arg.Name)
+	}
+
+	return arg.Source + "." + arg.Name
+}
+
+func (l literal) check(value string) (bool, error) {
+	var ok bool
+
+	switch l.match.Type {
+	case hook.MatchValue:
+		ok = value == l.match.Value
+	case hook.MatchRegex:
+		re, err := regexp.Compile(l.match.Regex)
+
+		if err != nil {
+			return false, fmt.Errorf("invalid regex %s: %s", l.match.Regex, err)
+		}
+
+		ok = re.MatchString(value)
+	default:
+		return false, fmt.Errorf("invalid match rule type %s", l.match.Type)
+	}
+
+	return ok != l.negated, nil
+}
+
+// candidates returns the values worth trying for a parameter constrained by the literals
+func candidates(literals []literal) ([]string, error) {
+	var result []string
+
+	for _, l := range literals {
+		if l.negated {
+			continue
+		}
+
+		switch l.match.Type {
+		case hook.MatchValue:
+			result = append(result, l.match.Value)
+		case hook.MatchRegex:
+			re, err := syntax.Parse(l.match.Regex, syntax.Perl)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex %s: %s", l.match.Regex, err)
+			}
+
+			re = re.Simplify()
+
+			for attempt := 0; attempt < maxGenerateAttempts; attempt++ {
+				result = append(result, generateString(re, attempt))
+			}
+		}
+	}
+
+	return append(result, negatedCandidates...), nil
+}
+
+func solve(r *hook.Rules, c conjunction, basePayload map[string]interface{}) (*Solution, error) {
+	var keys []string
+
+	parameters := make(map[string]hook.Argument)
+	literals := make(map[string][]literal)
+	signed := make(map[string][]*hook.MatchRule)
+
+	for _, l := range c {
+		arg := l.match.Parameter
+		key := parameterKey(arg)
+
+		if _, ok := parameters[key]; !ok {
+			keys = append(keys, key)
+			parameters[key] = arg
+		}
+
+		if l.match.Type == hook.MatchHashSHA1 {
+			switch {
+			case arg.Source == hook.SourcePayload:
+				return nil, fmt.Errorf("payload signature cannot be stored in the payload parameter %s", arg.Name)
+			case arg.Source == hook.SourceString:
+				return nil, fmt.Errorf("payload signature cannot be compared to a string")
+			case !l.negated:
+				signed[key] = append(signed[key], l.match)
+			}
+
+			continue
+		}
+
+		literals[key] = append(literals[key], l)
+	}
+
+	solution := &Solution{Header: make(map[string]string), Query: make(map[string]string)}
+
+	payload, err := copyPayload(basePayload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		arg := parameters[key]
+
+		if len(signed[key]) > 0 {
+			continue
+		}
+
+		if arg.Source == hook.SourceString {
+			for _, l := range literals[key] {
+				if ok, err := l.check(arg.Name); err != nil || !ok {
+					return nil, fmt.Errorf("constant string %s never satisfies the rule", arg.Name)
+				}
+			}
+
+			continue
+		}
+
+		positive := false
+
+		for _, l := range literals[key] {
+			positive = positive || !l.negated
+		}
+
+		if !positive {
+			continue
+		}
+
+		values, err := candidates(literals[key])
+
+		if err != nil {
+			return nil, err
+		}
+
+		value, found := "", false
+
+		for _, candidate := range values {
+			if found = satisfiesAll(literals[key], candidate); found {
+				value = candidate
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("no value of %s %s satisfies all the rules", arg.Source, arg.Name)
+		}
+
+		switch arg.Source {
+		case hook.SourceHeader:
+			solution.Header[textproto.CanonicalMIMEHeaderKey(arg.Name)] = value
+		case hook.SourceQuery:
+			solution.Query[arg.Name] = value
+		case hook.SourcePayload:
+			SetParameterValue(payload, strings.Split(arg.Name, "."), value)
+		}
+	}
+
+	if solution.Body, err = json.Marshal(payload); err != nil {
+		return nil, err
+	}
+
+	solution.Payload = payload
+
+	for _, key := range keys {
+		for _, match := range signed[key] {
+			signature := sha1SignaturePrefix + evaluator.Signature(solution.Body, match.Secret)
+
+			switch match.Parameter.Source {
+			case hook.SourceHeader:
+				solution.Header[textproto.CanonicalMIMEHeaderKey(match.Parameter.Name)] = signature
+			case hook.SourceQuery:
+				solution.Query[match.Parameter.Name] = signature
+			}
+		}
+	}
+
+	ok, err := evaluator.Evaluate(r, solution.request())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("conflicting rules on the same parameters")
+	}
+
+	return solution, nil
+}
+
+func satisfiesAll(literals []literal, value string) bool {
+	for _, l := range literals {
+		if ok, err := l.check(value); err != nil || !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func copyPayload(payload map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+
+	if payload == nil {
+		return result, nil
+	}
+
+	data, err := json.Marshal(payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+
+	return result, decoder.Decode(&result)
+}
+
+func (s *Solution) request() *fixture.Request {
+	query := url.Values{}
+
+	for name, value := range s.Query {
+		query.Set(name, value)
+	}
+
+	header := map[string]string{"Content-Type": "application/json"}
+
+	for name, value := range s.Header {
+		header[name] = value
+	}
+
+	r := fixture.NewRequest("POST", "/?"+query.Encode(), header, s.Body)
+	r.Normalize()
+
+	return r
+}
@@ -0,0 +1,153 @@
+package satisfier
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"regexp/syntax"
+	"testing"
+
+	"github.com/adnanh/hookman/evaluator"
+	"github.com/adnanh/webhook/hook"
+)
+
+func parseRule(t *testing.T, data string) *hook.Rules {
+	if data == "" {
+		return nil
+	}
+
+	var r hook.Rules
+
+	if err := json.Unmarshal([]byte(data), &r); err != nil {
+		t.Fatalf("invalid rule %s: %s", data, err)
+	}
+
+	return &r
+}
+
+func TestSatisfy(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+	}{
+		{"no rule", ``},
+		{"header value", `{"match": {"type": "value", "value": "secret", "parameter": {"source": "header", "name": "x-token"}}}`},
+		{"query regex", `{"match": {"type": "regex", "regex": "^v[0-9]+\\.[0-9]+$", "parameter": {"source": "url", "name": "version"}}}`},
+		{"nested payload", `{"match": {"type": "value", "value": "refs/heads/main", "parameter": {"source": "payload", "name": "push.ref"}}}`},
+		{"payload array", `{"match": {"type": "value", "value": "x", "parameter": {"source": "payload", "name": "commits.1.id"}}}`},
+		{"signature", `{"and": [
+			{"match": {"type": "value", "value": "push", "parameter": {"source": "payload", "name": "action"}}},
+			{"match": {"type": "payload-hash-sha1", "secret": "s3cr3t", "parameter": {"source": "header", "name": "X-Hub-Signature"}}}
+		]}`},
+		{"or takes the first satisfiable alternative", `{"or": [
+			{"match": {"type": "value", "value": "nope", "parameter": {"source": "string", "name": "constant"}}},
+			{"match": {"type": "value", "value": "b", "parameter": {"source": "header", "name": "X-B"}}}
+		]}`},
+		{"not", `{"not": {"match": {"type": "value", "value": "hookman", "parameter": {"source": "header", "name": "X-A"}}}}`},
+		{"regex and negated regex", `{"and": [
+			{"match": {"type": "regex", "regex": "^[a-z]+$", "parameter": {"source": "payload", "name": "name"}}},
+			{"not": {"match": {"type": "regex", "regex": "^x", "parameter": {"source": "payload", "name": "name"}}}}
+		]}`},
+		{"de morgan", `{"not": {"or": [
+			{"match": {"type": "value", "value": "x", "parameter": {"source": "url", "name": "a"}}},
+			{"not": {"match": {"type": "value", "value": "y", "parameter": {"source": "url", "name": "b"}}}}
+		]}}`},
+		{"constant string", `{"match": {"type": "regex", "regex": "^prod", "parameter": {"source": "string", "name": "production"}}}`},
+	}
+
+	for _, test := range tests {
+		r := parseRule(t, test.rule)
+		solution, err := Satisfy(r, map[string]interface{}{"base": true})
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+
+		if ok, err := evaluator.Evaluate(r, solution.request()); err != nil || !ok {
+			t.Errorf("%s: solution %+v does not satisfy the rule (%v)", test.name, solution, err)
+		}
+
+		if solution.Payload["base"] != true {
+			t.Errorf("%s: base payload was not kept: %v", test.name, solution.Payload)
+		}
+	}
+}
+
+func TestSatisfyErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+	}{
+		{"conflicting values", `{"and": [
+			{"match": {"type": "value", "value": "a", "parameter": {"source": "header", "name": "X-A"}}},
+			{"match": {"type": "value", "value": "b", "parameter": {"source": "header", "name": "x-a"}}}
+		]}`},
+		{"negated value", `{"and": [
+			{"match": {"type": "value", "value": "a", "parameter": {"source": "url", "name": "a"}}},
+			{"not": {"match": {"type": "value", "value": "a", "parameter": {"source": "url", "name": "a"}}}}
+		]}`},
+		{"constant string", `{"match": {"type": "value", "value": "b", "parameter": {"source": "string", "name": "a"}}}`},
+		{"signature in the payload", `{"match": {"type": "payload-hash-sha1", "secret": "s", "parameter": {"source": "payload", "name": "signature"}}}`},
+		{"invalid regex", `{"match": {"type": "regex", "regex": "(", "parameter": {"source": "url", "name": "a"}}}`},
+		{"invalid rule", `{}`},
+	}
+
+	for _, test := range tests {
+		if solution, err := Satisfy(parseRule(t, test.rule), nil); err == nil {
+			t.Errorf("%s: expected an error, got %+v", test.name, solution)
+		}
+	}
+}
+
+func TestSetParameterValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		node  interface{}
+		path  []string
+		value interface{}
+		want  interface{}
+	}{
+		{"empty path", map[string]interface{}{"a": 1}, nil, "v", "v"},
+		{"new object", nil, []string{"a", "b"}, "v", map[string]interface{}{"a": map[string]interface{}{"b": "v"}}},
+		{"existing object", map[string]interface{}{"a": 1}, []string{"b"}, "v", map[string]interface{}{"a": 1, "b": "v"}},
+		{"new array", nil, []string{"1"}, "v", []interface{}{nil, "v"}},
+		{"existing array", []interface{}{"x"}, []string{"0", "a"}, "v", []interface{}{map[string]interface{}{"a": "v"}}},
+		{"numeric key of an object", map[string]interface{}{}, []string{"1"}, "v", map[string]interface{}{"1": "v"}},
+		{"scalar replaced", map[string]interface{}{"a": "x"}, []string{"a", "b"}, "v", map[string]interface{}{"a": map[string]interface{}{"b": "v"}}},
+	}
+
+	for _, test := range tests {
+		if got := SetParameterValue(test.node, test.path, test.value); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %#v, want %#v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGenerateString(t *testing.T) {
+	patterns := []string{
+		`^abc$`,
+		`^[a-f0-9]{40}$`,
+		`^(push|pull_request)$`,
+		`^refs/(heads|tags)/.+`,
+		`^v?\d+\.\d+(\.\d+)?$`,
+		`[[:upper:]]\w*@example\.com`,
+		`^\S+ \S+$`,
+	}
+
+	for _, pattern := range patterns {
+		parsed, err := syntax.Parse(pattern, syntax.Perl)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		re := regexp.MustCompile(pattern)
+
+		for attempt := 0; attempt < 8; attempt++ {
+			if s := generateString(parsed.Simplify(), attempt); !re.MatchString(s) {
+				t.Errorf("%s: attempt %d generated %q", pattern, attempt, s)
+			}
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"regexp"
+
+	"github.com/codegangsta/cli"
+)
+
+// hooksSchema is the JSON Schema of the hooks file, the properties are the ones handled by
+// setHookProperties, other properties are allowed because hookman keeps the webhook keys
+// it does not know when it rewrites a hook
+const hooksSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "webhook hooks file",
+  "description": "Hooks served by webhook, as managed by hookman.",
+  "type": "array",
+  "items": {
+    "oneOf": [
+      { "$ref": "#/definitions/hook" },
+      { "$ref": "#/definitions/include" }
+    ]
+  },
+  "definitions": {
+    "hook": {
+      "type": "object",
+      "description": "A hook served at /hooks/<id>.",
+      "required": ["id", "execute-command"],
+      "properties": {
+        "id": {
+          "type": "string",
+          "description": "Identifier of the hook, used in the hook URL.",
+          "minLength": 1,
+          "pattern": "^[^/]+$"
+        },
+        "execute-command": {
+          "type": "string",
+          "description": "Command executed when the hook is triggered.",
+          "minLength": 1
+        },
+        "command-working-directory": {
+          "type": "string",
+          "description": "Working directory of the executed command."
+        },
+        "response-message": {
+          "type": "string",
+          "description": "Message returned to the hook initiator."
+        },
+        "include-command-output-in-response": {
+          "type": "boolean",
+          "description": "Wait for the command and return its output to the hook initiator.",
+          "default": false
+        },
+        "pass-environment-to-command": {
+          "$ref": "#/definitions/arguments",
+          "description": "Parameters passed to the command as HOOK_ prefixed environment variables."
+        },
+        "pass-arguments-to-command": {
+          "$ref": "#/definitions/arguments",
+          "description": "Parameters passed to the command as arguments, in order."
+        },
+        "parse-parameters-as-json": {
+          "$ref": "#/definitions/arguments",
+          "description": "Parameters holding JSON strings that are decoded before use."
+        },
+        "trigger-rule": {
+          "$ref": "#/definitions/rule",
+          "description": "Rule the request must satisfy to trigger the hook."
+        }
+      }
+    },
+    "include": {
+      "type": "object",
+      "description": "Hooks of the files matching the glob, relative to this file.",
+      "required": ["include"],
+      "properties": {
+        "include": {
+          "type": "string",
+          "minLength": 1
+        }
+      }
+    },
+    "arguments": {
+      "type": "array",
+      "items": { "$ref": "#/definitions/argument" }
+    },
+    "argument": {
+      "type": "object",
+      "required": ["source"],
+      "properties": {
+        "source": {
+          "type": "string",
+          "description": "Part of the request the value is taken from, string uses the name itself as the value.",
+          "enum": ["header", "url", "payload", "string", "entire-payload", "entire-query", "entire-headers"]
+        },
+        "name": {
+          "type": "string",
+          "description": "Name of the header, query parameter or dot separated payload path."
+        }
+      },
+      "if": {
+        "properties": { "source": { "enum": ["header", "url", "payload", "string"] } }
+      },
+      "then": {
+        "required": ["name"]
+      }
+    },
+    "rule": {
+      "type": "object",
+      "description": "Exactly one of and, or, not and match.",
+      "minProperties": 1,
+      "maxProperties": 1,
+      "properties": {
+        "and": {
+          "type": "array",
+          "description": "Satisfied when all of the rules are.",
+          "minItems": 1,
+          "items": { "$ref": "#/definitions/rule" }
+        },
+        "or": {
+          "type": "array",
+          "description": "Satisfied when any of the rules is.",
+          "minItems": 1,
+          "items": { "$ref": "#/definitions/rule" }
+        },
+        "not": {
+          "$ref": "#/definitions/rule",
+          "description": "Satisfied when the rule is not."
+        },
+        "match": {
+          "$ref": "#/definitions/match"
+        }
+      }
+    },
+    "match": {
+      "type": "object",
+      "description": "Compares a parameter of the request.",
+      "required": ["type", "parameter"],
+      "properties": {
+        "type": {
+          "type": "string",
+          "enum": ["value", "regex", "payload-hash-sha1"]
+        },
+        "value": {
+          "type": "string",
+          "description": "Value the parameter must be equal to."
+        },
+        "regex": {
+          "type": "string",
+          "description": "Regular expression the parameter must match.",
+          "format": "regex"
+        },
+        "secret": {
+          "type": "string",
+          "description": "Secret of the HMAC SHA1 signature of the payload held by the parameter."
+        },
+        "parameter": {
+          "$ref": "#/definitions/argument"
+        }
+      },
+      "allOf": [
+        {
+          "if": { "properties": { "type": { "const": "value" } } },
+          "then": { "required": ["value"] }
+        },
+        {
+          "if": { "properties": { "type": { "const": "regex" } } },
+          "then": { "required": ["regex"] }
+        },
+        {
+          "if": { "properties": { "type": { "const": "payload-hash-sha1" } } },
+          "then": { "required": ["secret"] }
+        }
+      ]
+    }
+  }
+}
+`
+
+// schemaReference is the schema the YAML hooks files are pointed to when they are written
+var schemaReference string
+
+// schemaModelinePattern matches the comment the YAML language server reads the schema from
+var schemaModelinePattern = regexp.MustCompile(`^#\s*yaml-language-server:\s*\$schema=(\S*)`)
+
+// schemaModeline returns the schema referenced in the leading comments of the YAML document
+// and the line it is referenced on
+func schemaModeline(data []byte) (string, int, bool) {
+	for idx, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+
+		if len(line) > 0 && line[0] != '#' {
+			break
+		}
+
+		if match := schemaModelinePattern.FindSubmatch(line); match != nil {
+			return string(match[1]), idx, true
+		}
+	}
+
+	return "", 0, false
+}
+
+// referenceSchema points the YAML hooks file to schemaReference, or keeps the schema it
+// already references when it is rewritten from scratch
+func referenceSchema(f *sourceFile, output []byte) []byte {
+	if f.format != formatYAML {
+		return output
+	}
+
+	reference := schemaReference
+
+	if reference == "" {
+		var ok bool
+
+		if reference, _, ok = schemaModeline(f.data); !ok {
+			return output
+		}
+	}
+
+	modeline := []byte("# yaml-language-server: $schema=" + reference)
+	lines := bytes.Split(output, []byte("\n"))
+
+	if _, idx, ok := schemaModeline(output); ok {
+		lines[idx] = modeline
+	} else {
+		lines = append([][]byte{modeline}, lines...)
+	}
+
+	return bytes.Join(lines, []byte("\n"))
+}
+
+func printSchema(c *cli.Context) {
+	if !c.IsSet("write") {
+		if _, err := os.Stdout.WriteString(hooksSchema); err != nil {
+			log.Fatalf("error: %s\n", err)
+		}
+
+		return
+	}
+
+	if c.String("write") == "" {
+		log.Fatalln("error: --write requires the path or URL of the schema")
+	}
+
+	lockHooks(c)
+
+	if err := loadHooks(c); err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	for _, f := range sourceFiles {
+		if f.format != formatYAML {
+			log.Fatalf("error: %s cannot reference the schema, its top level array has no place for a $schema key, map the schema to it in the editor settings instead\n", f.path)
+		}
+	}
+
+	schemaReference = c.String("write")
+	describeChange("schema", schemaReference)
+
+	if err := saveHooks(false); err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/adnanh/hookman/evaluator"
+	"github.com/adnanh/hookman/satisfier"
+	"github.com/adnanh/webhook/hook"
+	"github.com/codegangsta/cli"
+)
+
+const defaultWebhookURL = "http://localhost:9000/hooks/"
+
+// signatureRules returns all payload-hash-sha1 match rules from the given rule tree
+func signatureRules(r *hook.Rules) []*hook.MatchRule {
+	var result []*hook.MatchRule
+
+	switch {
+	case r == nil:
+	case r.And != nil:
+		for i := range *r.And {
+			result = append(result, signatureRules(&(*r.And)[i])...)
+		}
+	case r.Or != nil:
+		for i := range *r.Or {
+			result = append(result, signatureRules(&(*r.Or)[i])...)
+		}
+	case r.Not != nil:
+		result = append(result, signatureRules((*hook.Rules)(r.Not))...)
+	case r.Match != nil && r.Match.Type == hook.MatchHashSHA1:
+		result = append(result, r.Match)
+	}
+
+	return result
+}
+
+func loadPayloadFile(c *cli.Context) []byte {
+	if !c.IsSet("payload") {
+		return []byte("{}")
+	}
+
+	payload, err := ioutil.ReadFile(c.String("payload"))
+
+	if err != nil {
+		log.Fatalf("error: could not read payload: %s\n", err)
+	}
+
+	return payload
+}
+
+func signPayload(c *cli.Context) {
+	if err := loadHooks(c); err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	if !c.IsSet("hook") {
+		log.Fatalln("error: you must supply --hook id")
+	}
+
+	h, err := findOneHook(c.String("hook"), c.IsSet("idx"), c.Int("idx"))
+
+	if err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	rules := signatureRules(h.TriggerRule)
+
+	if len(rules) == 0 {
+		log.Fatalf("error: hook %s has no payload-hash-sha1 rule\n", h.ID)
+	}
+
+	payload := loadPayloadFile(c)
+
+	for _, r := range rules {
+		signature := "sha1=" + evaluator.Signature(payload, r.Secret)
+
+		if r.Parameter.Source == hook.SourceHeader {
+			fmt.Printf("%s: %s\n", r.Parameter.Name, signature)
+		} else {
+			fmt.Printf("%s.%s=%s\n", r.Parameter.Source, r.Parameter.Name, signature)
+		}
+	}
+}
+
+// loadBasePayload returns the JSON object from the --payload file, or an empty one
+func loadBasePayload(c *cli.Context) map[string]interface{} {
+	payload := make(map[string]interface{})
+
+	decoder := json.NewDecoder(bytes.NewReader(loadPayloadFile(c)))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&payload); err != nil {
+		log.Fatalf("error: payload must be a JSON object: %s\n", err)
+	}
+
+	return payload
+}
+
+// satisfyHook returns the request values satisfying the trigger rule of the given hook
+func satisfyHook(c *cli.Context, h *hook.Hook) *satisfier.Solution {
+	solution, err := satisfier.Satisfy(h.TriggerRule, loadBasePayload(c))
+
+	if err != nil {
+		log.Fatalf("error: trigger rule of hook %s cannot be satisfied: %s\n", h.ID, err)
+	}
+
+	return solution
+}
+
+func fireHook(c *cli.Context) {
+	if err := loadHooks(c); err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	h, err := findOneHookByID(c)
+
+	if err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	target := c.String("url")
+
+	if target == "" {
+		target = defaultWebhookURL + url.PathEscape(h.ID)
+	}
+
+	u, err := url.Parse(target)
+
+	if err != nil {
+		log.Fatalf("error: invalid url %s\n", target)
+	}
+
+	solution := satisfyHook(c, h)
+
+	query := u.Query()
+
+	for name, value := range solution.Query {
+		query.Set(name, value)
+	}
+
+	u.RawQuery = query.Encode()
+
+	request, err := http.NewRequest("POST", u.String(), bytes.NewReader(solution.Body))
+
+	if err != nil {
+		log.Fatalf("error: %s\n", err)
+	}
+
+	for name, value := range solution.Header {
+		request.Header.Set(name, value)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := http.DefaultClient.Do(request)
+
+	if err != nil {
+		log.Fatalf("error: could not fire hook: %s\n", err)
+	}
+
+	defer response.Body.Close()
+
+	responseBody, _ := ioutil.ReadAll(response.Body)
+
+	log.Printf("%s\n", response.Status)
+	fmt.Printf("%s", responseBody)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// stdioFile is the hooks file name that reads the hooks from stdin and writes them to stdout
+const stdioFile = "-"
+
+// sniffFormat guesses the format of a hooks document that has no file extension
+func sniffFormat(data []byte) string {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+
+	switch {
+	case len(trimmed) == 0:
+		return formatJSON
+	case trimmed[0] == '[' && json.Valid(trimmed):
+		return formatJSON
+	case trimmed[0] == '[' || trimmed[0] == '/':
+		return formatJSON5
+	default:
+		return formatYAML
+	}
+}
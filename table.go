@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/adnanh/webhook/hook"
+	"github.com/codegangsta/cli"
+)
+
+const (
+	tableColumnGap      = "  "
+	tableMinColumnWidth = 8
+)
+
+// tableColumn is a column of the list --table output, wide columns are truncated to make
+// the table fit the terminal
+type tableColumn struct {
+	header  string
+	value   func(h *hook.Hook) string
+	numeric bool
+	wide    bool
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+
+	return "no"
+}
+
+var tableColumns = map[string]tableColumn{
+	"id":  {header: "ID", value: func(h *hook.Hook) string { return h.ID }},
+	"idx": {header: "IDX", numeric: true, value: func(h *hook.Hook) string { return strconv.Itoa(localIndex(h)) }},
+	"command": {header: "COMMAND", wide: true, value: func(h *hook.Hook) string {
+		return h.ExecuteCommand
+	}},
+	"cwd": {header: "CWD", wide: true, value: func(h *hook.Hook) string {
+		return h.CommandWorkingDirectory
+	}},
+	"has-rule": {header: "RULE", value: func(h *hook.Hook) string { return yesNo(h.TriggerRule != nil) }},
+	"rule": {header: "RULE", wide: true, value: func(h *hook.Hook) string {
+		if h.TriggerRule == nil {
+			return ""
+		}
+
+		return fmt.Sprintf("%s", (*Rules)(h.TriggerRule))
+	}},
+	"args": {header: "ARGS", numeric: true, value: func(h *hook.Hook) string {
+		return strconv.Itoa(len(h.PassArgumentsToCommand))
+	}},
+	"capture-output": {header: "CAPTURE", value: func(h *hook.Hook) string { return yesNo(h.CaptureCommandOutput) }},
+	"file": {header: "FILE", wide: true, value: func(h *hook.Hook) string {
+		return hookSources[hookIndex(h)].file.path
+	}},
+}
+
+func tableColumnNames() string {
+	names := make([]string, 0, len(tableColumns))
+
+	for name := range tableColumns {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return strings.Join(names, ", ")
+}
+
+// truncate shortens the string to the given number of characters, ending it with an ellipsis
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+
+	if width <= 1 {
+		return string([]rune(s)[:width])
+	}
+
+	return string([]rune(s)[:width-1]) + "…"
+}
+
+// writeHooksTable writes the hooks as an aligned table to stdout, truncating the wide
+// columns to the terminal width
+func writeHooksTable(c *cli.Context) {
+	names := strings.Split(c.String("columns"), ",")
+
+	if !c.IsSet("columns") && len(sourceFiles) > 1 {
+		names = append(names, "file")
+	}
+
+	var columns []tableColumn
+
+	for i, name := range names {
+		names[i] = strings.ToLower(strings.TrimSpace(name))
+		column, ok := tableColumns[names[i]]
+
+		if !ok {
+			log.Fatalf("error: unknown column %s, expected one of %s\n", name, tableColumnNames())
+		}
+
+		columns = append(columns, column)
+	}
+
+	var selected []*hook.Hook
+
+	if len(c.Args()) == 0 {
+		for _, id := range hooksIds {
+			selected = append(selected, hooksMap[id]...)
+		}
+	} else {
+		selected = selectedHooks(c)
+	}
+
+	rows := make([][]string, len(selected))
+
+	for i, h := range selected {
+		rows[i] = make([]string, len(columns))
+
+		for j, column := range columns {
+			value := string(restoreTemplateActions([]byte(column.value(h))))
+			rows[i][j] = strings.NewReplacer("\n", " ", "\t", " ").Replace(value)
+		}
+	}
+
+	if sortBy := strings.ToLower(c.String("sort")); sortBy != "" {
+		descending := strings.HasPrefix(sortBy, "-")
+		sortBy = strings.TrimPrefix(sortBy, "-")
+		position := -1
+
+		for j, name := range names {
+			if name == sortBy {
+				position = j
+			}
+		}
+
+		column, ok := tableColumns[sortBy]
+
+		if !ok {
+			log.Fatalf("error: unknown column %s, expected one of %s\n", sortBy, tableColumnNames())
+		}
+
+		keys := make([]string, len(selected))
+
+		for i, h := range selected {
+			if keys[i] = column.value(h); position >= 0 {
+				keys[i] = rows[i][position]
+			}
+		}
+
+		indexes := make([]int, len(rows))
+
+		for i := range indexes {
+			indexes[i] = i
+		}
+
+		sort.SliceStable(indexes, func(a, b int) bool {
+			l, r := keys[indexes[a]], keys[indexes[b]]
+
+			if descending {
+				l, r = r, l
+			}
+
+			if column.numeric {
+				ln, _ := strconv.Atoi(l)
+				rn, _ := strconv.Atoi(r)
+
+				return ln < rn
+			}
+
+			return l < r
+		})
+
+		sorted := make([][]string, len(rows))
+
+		for i, index := range indexes {
+			sorted[i] = rows[index]
+		}
+
+		rows = sorted
+	}
+
+	widths := make([]int, len(columns))
+
+	for j, column := range columns {
+		widths[j] = utf8.RuneCountInString(column.header)
+
+		for _, row := range rows {
+			if n := utf8.RuneCountInString(row[j]); n > widths[j] {
+				widths[j] = n
+			}
+		}
+	}
+
+	width, ok := terminalWidth(os.Stdout)
+
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		width, ok = n, true
+	}
+
+	if ok {
+		fitTableWidths(columns, widths, width)
+	}
+
+	var out bytes.Buffer
+
+	writeRow := func(cells []string) {
+		var line []string
+
+		for j, cell := range cells {
+			cell = truncate(cell, widths[j])
+
+			if j < len(cells)-1 {
+				cell += strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell))
+			}
+
+			line = append(line, cell)
+		}
+
+		out.WriteString(strings.TrimRight(strings.Join(line, tableColumnGap), " ") + "\n")
+	}
+
+	headers := make([]string, len(columns))
+
+	for j, column := range columns {
+		headers[j] = column.header
+	}
+
+	writeRow(headers)
+
+	for _, row := range rows {
+		writeRow(row)
+	}
+
+	os.Stdout.Write(out.Bytes())
+}
+
+// fitTableWidths narrows the widest of the wide columns one character at a time until the
+// table fits the width or the wide columns cannot be narrowed any more
+func fitTableWidths(columns []tableColumn, widths []int, width int) {
+	total := func() int {
+		sum := len(tableColumnGap) * (len(widths) - 1)
+
+		for _, w := range widths {
+			sum += w
+		}
+
+		return sum
+	}
+
+	for total() > width {
+		widest := -1
+
+		for j, column := range columns {
+			if column.wide && widths[j] > tableMinColumnWidth && (widest < 0 || widths[j] > widths[widest]) {
+				widest = j
+			}
+		}
+
+		if widest < 0 {
+			return
+		}
+
+		widths[widest]--
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/adnanh/hookman/document"
+	"github.com/adnanh/webhook/hook"
+)
+
+// templateAction is a webhook template action replaced by a placeholder while the hooks
+// file is edited
+type templateAction struct {
+	placeholder string
+	action      []byte
+	bare        bool
+}
+
+var (
+	templateMode    bool
+	templateActions []templateAction
+
+	placeholderPattern     = regexp.MustCompile(`"?__hookman_template_[0-9]+__"?`)
+	placeholderTextPattern = regexp.MustCompile(`__hookman_template_[0-9]+__`)
+)
+
+// protectTemplateActions replaces the template actions in the given hooks file contents
+// with placeholders that parse as strings, actions outside of strings get a quoted
+// placeholder unless the format is YAML where a plain one is a string already
+func protectTemplateActions(data []byte, format string) []byte {
+	var out bytes.Buffer
+	var quote byte
+
+	for i := 0; i < len(data); {
+		if bytes.HasPrefix(data[i:], []byte("{{")) {
+			if end := bytes.Index(data[i+2:], []byte("}}")); end >= 0 {
+				action := templateAction{
+					placeholder: fmt.Sprintf("__hookman_template_%d__", len(templateActions)),
+					action:      append([]byte(nil), data[i:i+end+4]...),
+					bare:        quote == 0 && format != formatYAML,
+				}
+
+				templateActions = append(templateActions, action)
+
+				if action.bare {
+					out.WriteString(`"` + action.placeholder + `"`)
+				} else {
+					out.WriteString(action.placeholder)
+				}
+
+				i += len(action.action)
+				continue
+			}
+		}
+
+		c := data[i]
+
+		switch {
+		case quote != 0 && c == '\\' && i+1 < len(data):
+			out.Write(data[i : i+2])
+			i += 2
+			continue
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\'' && format == formatJSON5):
+			quote = c
+		}
+
+		out.WriteByte(c)
+		i++
+	}
+
+	return out.Bytes()
+}
+
+// protectTemplateValue replaces the template actions in a value given on the command line
+func protectTemplateValue(value string) string {
+	if !templateMode {
+		return value
+	}
+
+	return string(protectTemplateActions([]byte(value), formatYAML))
+}
+
+// restoreTemplateActions puts the template actions back in place of their placeholders
+func restoreTemplateActions(data []byte) []byte {
+	if len(templateActions) == 0 {
+		return data
+	}
+
+	return placeholderPattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		placeholder := bytes.Trim(match, `"`)
+
+		var n int
+
+		if _, err := fmt.Sscanf(string(placeholder), "__hookman_template_%d__", &n); err != nil || n >= len(templateActions) {
+			return match
+		}
+
+		action := templateActions[n]
+
+		if action.bare && len(match) == len(placeholder)+2 {
+			return action.action
+		}
+
+		return bytes.Replace(match, placeholder, action.action, 1)
+	})
+}
+
+// templatedProperty reports whether the raw value of the given hook property holds a
+// template action where webhook expects something else than a string, for example
+// "include-command-output-in-response": {{ getenv "INCLUDE" }}, such properties are kept
+// out of the decoded hook and only live in the hook document
+func templatedProperty(key string, raw json.RawMessage) bool {
+	property, known := hookProperties[strings.ToLower(key)]
+
+	if len(templateActions) == 0 || !known || !placeholderPattern.Match(raw) {
+		return false
+	}
+
+	value := reflect.New(reflect.TypeOf(hook.Hook{}).Field(property.field).Type)
+
+	return json.Unmarshal(raw, value.Interface()) != nil
+}
+
+// templatedHookProperty reports whether the given property of a loaded hook is a
+// templated property, see templatedProperty
+func templatedHookProperty(h *hook.Hook, property string) bool {
+	idx := hookIndex(h)
+
+	if idx < 0 || hookSources[idx].document == nil {
+		return false
+	}
+
+	o := hookSources[idx].document
+
+	for _, key := range o.Keys {
+		if strings.EqualFold(key, property) && templatedProperty(key, o.Values[key]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decodeHook decodes the hook document, leaving out the templated properties
+func decodeHook(o *document.Object, h *hook.Hook) error {
+	typed := document.New()
+
+	for _, key := range o.Keys {
+		if !templatedProperty(key, o.Values[key]) {
+			typed.Set(key, o.Values[key])
+		}
+	}
+
+	return typed.Decode(h)
+}
+
+// restoreTemplateStrings puts the template actions back in place of their placeholders in
+// the strings of the given JSON document, unlike restoreTemplateActions every action ends
+// up inside a string value so that the document stays valid JSON
+func restoreTemplateStrings(data []byte) []byte {
+	if len(templateActions) == 0 {
+		return data
+	}
+
+	var out bytes.Buffer
+
+	for i := 0; i < len(data); {
+		if data[i] != '"' {
+			out.WriteByte(data[i])
+			i++
+			continue
+		}
+
+		end := i + 1
+
+		for end < len(data) && data[end] != '"' {
+			if data[end] == '\\' {
+				end++
+			}
+
+			end++
+		}
+
+		if end >= len(data) {
+			out.Write(data[i:])
+			break
+		}
+
+		literal := data[i : end+1]
+		i = end + 1
+
+		var s string
+
+		if !placeholderTextPattern.Match(literal) || json.Unmarshal(literal, &s) != nil {
+			out.Write(literal)
+			continue
+		}
+
+		s = placeholderTextPattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+			var n int
+
+			if _, err := fmt.Sscanf(placeholder, "__hookman_template_%d__", &n); err != nil || n >= len(templateActions) {
+				return placeholder
+			}
+
+			return string(templateActions[n].action)
+		})
+
+		encoded, err := encodeJSON(s, "", "")
+
+		if err != nil {
+			out.Write(literal)
+			continue
+		}
+
+		out.Write(encoded)
+	}
+
+	return out.Bytes()
+}
+
+// parseableHooksData returns the hooks file contents the codecs can parse
+func parseableHooksData(data []byte, format string) []byte {
+	if !templateMode {
+		return data
+	}
+
+	return protectTemplateActions(data, format)
+}
+
+// templateOutput shows the template actions in place of their placeholders in the messages
+type templateOutput struct {
+	w io.Writer
+}
+
+func (o templateOutput) Write(p []byte) (int, error) {
+	if _, err := o.w.Write(restoreTemplateActions(p)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loadTemplateHooks loads the given hooks file contents in template mode
+func loadTemplateHooks(t *testing.T, name, data string) *sourceFile {
+	dir, err := ioutil.TempDir("", "hookman")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, name)
+
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	templateMode, templateActions = true, nil
+	hooks, hookSources, sourceFiles = nil, nil, nil
+
+	f, err := loadHooksFile("", path, nil)
+
+	if err != nil {
+		t.Fatalf("%s: %s", name, err)
+	}
+
+	return f
+}
+
+func TestTemplateActions(t *testing.T) {
+	defer func() {
+		templateMode, templateActions = false, nil
+		hooks, hookSources, sourceFiles = nil, nil, nil
+	}()
+
+	tests := []struct {
+		name    string
+		file    string
+		data    string
+		command string
+		capture bool
+	}{
+		{
+			"json string", "hooks.json",
+			"[\n  {\n    \"id\": \"a\",\n    \"execute-command\": \"{{ getenv \"CMD\" }}\"\n  }\n]\n",
+			"__hookman_template_0__", false,
+		},
+		{
+			"json bare bool", "hooks.json",
+			"[\n  {\n    \"id\": \"a\",\n    \"execute-command\": \"/bin/a\",\n    \"include-command-output-in-response\": {{ getenv \"INC\" }}\n  }\n]\n",
+			"/bin/a", false,
+		},
+		{
+			"json bare rule", "hooks.json",
+			"[\n  {\n    \"id\": \"a\",\n    \"execute-command\": \"/bin/a\",\n    \"trigger-rule\": {{ getenv \"RULE\" }},\n    \"include-command-output-in-response\": true\n  }\n]\n",
+			"/bin/a", true,
+		},
+		{
+			"yaml string", "hooks.yaml",
+			"- id: a\n  execute-command: {{ getenv \"CMD\" }}\n",
+			"__hookman_template_0__", false,
+		},
+		{
+			"yaml bool", "hooks.yaml",
+			"- id: a\n  execute-command: /bin/a\n  include-command-output-in-response: {{ getenv \"INC\" }}\n",
+			"/bin/a", false,
+		},
+	}
+
+	for _, test := range tests {
+		f := loadTemplateHooks(t, test.file, test.data)
+
+		if len(hooks) != 1 {
+			t.Errorf("%s: got %d hooks, want 1", test.name, len(hooks))
+			continue
+		}
+
+		if hooks[0].ExecuteCommand != test.command || hooks[0].CaptureCommandOutput != test.capture {
+			t.Errorf("%s: got command %q and capture %v, want %q and %v", test.name, hooks[0].ExecuteCommand, hooks[0].CaptureCommandOutput, test.command, test.capture)
+		}
+
+		hooks[0].CommandWorkingDirectory = "/tmp"
+
+		output, err := formatHooks(f, false)
+
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		if string(output) == test.data || !containsAll(string(output), templateActions) {
+			t.Errorf("%s: template actions not kept\n%s", test.name, output)
+		}
+
+		hooks[0].CommandWorkingDirectory = ""
+
+		if output, _ := formatHooks(f, true); !containsAll(string(output), templateActions) {
+			t.Errorf("%s: template actions not kept when reformatting\n%s", test.name, output)
+		}
+	}
+}
+
+func TestTemplatedPropertyEdits(t *testing.T) {
+	defer func() {
+		templateMode, templateActions = false, nil
+		hooks, hookSources, sourceFiles = nil, nil, nil
+	}()
+
+	loadTemplateHooks(t, "hooks.json", "[{\"id\": \"a\", \"include-command-output-in-response\": {{ getenv \"INC\" }}}]")
+
+	if err := unsetHookProperties(&hooks[0], []string{"include-response"}); err == nil {
+		t.Errorf("unset: expected an error")
+	}
+
+	if err := setHookProperties(&hooks[0], []string{"include-response=false"}); err == nil {
+		t.Errorf("set to false: expected an error")
+	}
+
+	if err := setHookProperties(&hooks[0], []string{"include-response=true"}); err != nil {
+		t.Errorf("set to true: unexpected error: %s", err)
+	}
+
+	if err := unsetHookProperties(&hooks[0], []string{"cmd"}); err != nil {
+		t.Errorf("unset another property: unexpected error: %s", err)
+	}
+}
+
+func containsAll(s string, actions []templateAction) bool {
+	for _, action := range actions {
+		if !strings.Contains(s, string(action.action)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestRestoreTemplateStrings(t *testing.T) {
+	defer func() {
+		templateActions = nil
+	}()
+
+	templateActions = []templateAction{
+		{placeholder: "__hookman_template_0__", action: []byte(`{{ getenv "INC" }}`), bare: true},
+		{placeholder: "__hookman_template_1__", action: []byte(`{{ .Value | js }}`)},
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"bare action", `{"a":"__hookman_template_0__"}`, `{"a":"{{ getenv \"INC\" }}"}`},
+		{"inside a string", `["x __hookman_template_1__ y"]`, `["x {{ .Value | js }} y"]`},
+		{"escaped quotes", `{"a\"b":"\"__hookman_template_0__\""}`, `{"a\"b":"\"{{ getenv \"INC\" }}\""}`},
+		{"unknown placeholder", `["__hookman_template_7__"]`, `["__hookman_template_7__"]`},
+		{"no placeholders", `{"a": [1, true, "b"]}`, `{"a": [1, true, "b"]}`},
+	}
+
+	for _, test := range tests {
+		got := string(restoreTemplateStrings([]byte(test.input)))
+
+		if got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+
+		var v interface{}
+
+		if err := json.Unmarshal([]byte(got), &v); err != nil {
+			t.Errorf("%s: result is not valid JSON: %s", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// terminalWidth returns the width of the terminal the file is attached to, ok is false if
+// it is not a terminal
+func terminalWidth(f *os.File) (int, bool) {
+	var size struct {
+		rows, cols, x, y uint16
+	}
+
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size))); errno != 0 {
+		return 0, false
+	}
+
+	return int(size.cols), true
+}
@@ -0,0 +1,31 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var procGetConsoleScreenBufferInfo = syscall.NewLazyDLL("kernel32.dll").NewProc("GetConsoleScreenBufferInfo")
+
+type consoleScreenBufferInfo struct {
+	size, cursorPosition     struct{ x, y int16 }
+	attributes               uint16
+	left, top, right, bottom int16
+	maximumWindowSize        struct{ x, y int16 }
+}
+
+// terminalWidth returns the width of the console the file is attached to, ok is false if
+// it is not a console
+func terminalWidth(f *os.File) (int, bool) {
+	var info consoleScreenBufferInfo
+
+	if r, _, _ := procGetConsoleScreenBufferInfo.Call(f.Fd(), uintptr(unsafe.Pointer(&info))); r == 0 {
+		return 0, false
+	}
+
+	return int(info.right-info.left) + 1, true
+}
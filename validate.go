@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/adnanh/hookman/validator"
+	"github.com/codegangsta/cli"
+)
+
+// Exit codes of the validate command
+const (
+	validateOK       = 0
+	validateFindings = 1
+	validateLoad     = 2
+)
+
+// validationFinding is a finding in the validate output
+type validationFinding struct {
+	File     string             `json:"file"`
+	Position int                `json:"position"`
+	ID       string             `json:"id"`
+	Index    int                `json:"index"`
+	Severity validator.Severity `json:"severity"`
+	Check    string             `json:"check"`
+	Message  string             `json:"message"`
+}
+
+// validationResult is the validate output
+type validationResult struct {
+	Findings []validationFinding `json:"findings"`
+	Errors   int                 `json:"errors"`
+	Warnings int                 `json:"warnings"`
+}
+
+func validateHooks(c *cli.Context) {
+	format := outputFormat(c)
+
+	if err := loadHooks(c); err != nil {
+		log.Printf("error: %s\n", err)
+		os.Exit(validateLoad)
+	}
+
+	options := validator.Options{
+		Filesystem: !c.Bool("no-filesystem"),
+		Opaque: func(value string) bool {
+			return placeholderPattern.MatchString(value) || variablePattern.MatchString(value)
+		},
+		Locate: func(position int) string {
+			return fmt.Sprintf("%s[%d]", hookSources[position].file.path, hookSources[position].index)
+		},
+	}
+
+	result := validationResult{Findings: make([]validationFinding, 0)}
+
+	for _, finding := range validator.Validate(hooks, options) {
+		h := &hooks[finding.Position]
+		source := hookSources[finding.Position]
+
+		result.Findings = append(result.Findings, validationFinding{
+			File:     source.file.path,
+			Position: source.index,
+			ID:       h.ID,
+			Index:    localIndex(h),
+			Severity: finding.Severity,
+			Check:    finding.Check,
+			Message:  finding.Message,
+		})
+
+		if finding.Severity == validator.SeverityError {
+			result.Errors++
+		} else {
+			result.Warnings++
+		}
+	}
+
+	if format == outputText {
+		for _, finding := range result.Findings {
+			log.Printf("%s[%d]: %s [%d]: %s: %s (%s)\n", finding.File, finding.Position, finding.ID, finding.Index, finding.Severity, finding.Message, finding.Check)
+		}
+
+		log.Printf("%d error(s), %d warning(s) in %s\n", result.Errors, result.Warnings, describeHooksFiles())
+	} else {
+		writeOutput(format, result)
+	}
+
+	if result.Errors > 0 || (c.Bool("strict") && result.Warnings > 0) {
+		os.Exit(validateFindings)
+	}
+
+	os.Exit(validateOK)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestValidateExitCodes runs the validate command in a child process, the test binary
+// acts as hookman when HOOKMAN_TEST_ARGS is set
+func TestValidateExitCodes(t *testing.T) {
+	if args := os.Getenv("HOOKMAN_TEST_ARGS"); args != "" {
+		os.Args = append([]string{"hookman"}, strings.Split(args, " ")...)
+		main()
+		return
+	}
+
+	dir, err := ioutil.TempDir("", "hookman")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"valid.json":     `[{"id": "a", "execute-command": "/bin/a"}]`,
+		"warning.json":   `[{"id": "a b", "execute-command": "/bin/a"}]`,
+		"duplicate.json": `[{"id": "a", "execute-command": "/bin/a"}, {"id": "a", "execute-command": "/bin/b"}]`,
+		"invalid.json":   `[{"id": "a",`,
+	}
+
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		args string
+		want int
+	}{
+		{"valid", "valid.json validate", validateOK},
+		{"warning", "warning.json validate", validateOK},
+		{"strict warning", "warning.json validate --strict", validateFindings},
+		{"duplicate id", "duplicate.json validate", validateFindings},
+		{"json output", "duplicate.json validate -o json", validateFindings},
+		{"unloadable", "invalid.json validate", validateLoad},
+		{"missing", "missing.json validate", validateLoad},
+	}
+
+	for _, test := range tests {
+		split := strings.SplitN(test.args, " ", 2)
+
+		cmd := exec.Command(os.Args[0], "-test.run=^TestValidateExitCodes$")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "HOOKMAN_TEST_ARGS=--no-history -f "+split[0]+" "+split[1]+" --no-filesystem")
+
+		output, err := cmd.CombinedOutput()
+
+		code := 0
+
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			code = exitErr.Sys().(interface{ ExitStatus() int }).ExitStatus()
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		if code != test.want {
+			t.Errorf("%s: got exit code %d, want %d\n%s", test.name, code, test.want, output)
+		}
+	}
+}
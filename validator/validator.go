@@ -0,0 +1,240 @@
+package validator
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/adnanh/webhook/hook"
+)
+
+// Severity of a finding, errors make webhook misbehave while warnings point at likely mistakes
+type Severity string
+
+const (
+	// SeverityError is the severity of the problems that break the hook
+	SeverityError Severity = "error"
+
+	// SeverityWarning is the severity of the likely mistakes
+	SeverityWarning Severity = "warning"
+)
+
+// Names of the checks reported in the findings
+const (
+	CheckEmptyID          = "empty-id"
+	CheckUnsafeID         = "unsafe-id"
+	CheckDuplicateID      = "duplicate-id"
+	CheckMissingCommand   = "missing-command"
+	CheckCommand          = "command"
+	CheckWorkingDirectory = "working-directory"
+	CheckInvalidRegex     = "invalid-regex"
+	CheckInvalidMatch     = "invalid-match"
+	CheckEmptyGroup       = "empty-group"
+	CheckInvalidArgument  = "invalid-argument"
+)
+
+// Finding is a problem found in the hook at the given position of the validated hooks
+type Finding struct {
+	Position int
+	Severity Severity
+	Check    string
+	Message  string
+}
+
+// Options control the checks that depend on the machine the validation runs on
+type Options struct {
+	// Filesystem enables the checks of the command and the working directory on disk
+	Filesystem bool
+
+	// Opaque returns true for the values that are filled in later, such as template actions,
+	// which are not checked
+	Opaque func(value string) bool
+
+	// Locate returns how the messages refer to the hook at the given position of the
+	// validated hooks, like its file and its position in the file
+	Locate func(position int) string
+}
+
+type validation struct {
+	options  Options
+	findings []Finding
+	position int
+}
+
+func (v *validation) report(severity Severity, check, format string, args ...interface{}) {
+	v.findings = append(v.findings, Finding{Position: v.position, Severity: severity, Check: check, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validation) opaque(value string) bool {
+	return v.options.Opaque != nil && v.options.Opaque(value)
+}
+
+func (v *validation) locate(position int) string {
+	if v.options.Locate == nil {
+		return fmt.Sprintf("position %d", position)
+	}
+
+	return v.options.Locate(position)
+}
+
+// Validate checks the hooks and returns the findings ordered by hook position
+func Validate(hooks []hook.Hook, options Options) []Finding {
+	v := &validation{options: options}
+	seen := make(map[string]int)
+
+	for i := range hooks {
+		h := &hooks[i]
+		v.position = i
+
+		switch {
+		case h.ID == "":
+			v.report(SeverityError, CheckEmptyID, "hook has no id")
+		case strings.Contains(h.ID, "/"):
+			v.report(SeverityError, CheckUnsafeID, "id %q contains a slash and cannot be reached through the hooks url", h.ID)
+		case url.PathEscape(h.ID) != h.ID:
+			v.report(SeverityWarning, CheckUnsafeID, "id %q must be escaped in the hooks url as %s", h.ID, url.PathEscape(h.ID))
+		}
+
+		if first, ok := seen[h.ID]; ok && h.ID != "" {
+			v.report(SeverityError, CheckDuplicateID, "id %q is already used by the hook at %s, webhook never triggers this one", h.ID, v.locate(first))
+		} else {
+			seen[h.ID] = i
+		}
+
+		v.checkCommand(h)
+
+		v.checkArguments("pass-arguments-to-command", h.PassArgumentsToCommand)
+		v.checkArguments("pass-environment-to-command", h.PassEnvironmentToCommand)
+		v.checkArguments("parse-parameters-as-json", h.JSONStringParameters)
+
+		if h.TriggerRule != nil {
+			v.checkRule(h.TriggerRule, "trigger-rule")
+		}
+	}
+
+	return v.findings
+}
+
+func (v *validation) checkCommand(h *hook.Hook) {
+	if h.ExecuteCommand == "" {
+		v.report(SeverityError, CheckMissingCommand, "hook has no execute-command")
+	}
+
+	if !v.options.Filesystem {
+		return
+	}
+
+	if h.CommandWorkingDirectory != "" && !v.opaque(h.CommandWorkingDirectory) {
+		if info, err := os.Stat(h.CommandWorkingDirectory); err != nil {
+			v.report(SeverityError, CheckWorkingDirectory, "command-working-directory %s does not exist", h.CommandWorkingDirectory)
+			return
+		} else if !info.IsDir() {
+			v.report(SeverityError, CheckWorkingDirectory, "command-working-directory %s is not a directory", h.CommandWorkingDirectory)
+			return
+		}
+	}
+
+	if h.ExecuteCommand == "" || v.opaque(h.ExecuteCommand) {
+		return
+	}
+
+	command := h.ExecuteCommand
+
+	if !strings.ContainsRune(command, '/') && !strings.ContainsRune(command, filepath.Separator) {
+		if _, err := exec.LookPath(command); err != nil {
+			v.report(SeverityError, CheckCommand, "execute-command %s is not found in PATH", command)
+		}
+
+		return
+	}
+
+	if !filepath.IsAbs(command) && h.CommandWorkingDirectory != "" {
+		command = filepath.Join(h.CommandWorkingDirectory, command)
+	}
+
+	info, err := os.Stat(command)
+
+	switch {
+	case err != nil:
+		v.report(SeverityError, CheckCommand, "execute-command %s does not exist", command)
+	case info.IsDir():
+		v.report(SeverityError, CheckCommand, "execute-command %s is a directory", command)
+	case runtime.GOOS != "windows" && info.Mode()&0111 == 0:
+		v.report(SeverityError, CheckCommand, "execute-command %s is not executable", command)
+	}
+}
+
+func (v *validation) checkArguments(property string, args []hook.Argument) {
+	for i, arg := range args {
+		v.checkArgument(fmt.Sprintf("%s[%d]", property, i), arg)
+	}
+}
+
+// checkArgument checks the source of the argument, webhook matches the sources case-sensitively
+func (v *validation) checkArgument(path string, arg hook.Argument) {
+	switch arg.Source {
+	case hook.SourceEntirePayload, hook.SourceEntireQuery, hook.SourceEntireHeaders:
+	case hook.SourceHeader, hook.SourceQuery, hook.SourcePayload, hook.SourceString:
+		if arg.Name == "" {
+			v.report(SeverityError, CheckInvalidArgument, "%s has no name", path)
+		}
+	case "":
+		v.report(SeverityError, CheckInvalidArgument, "%s has no source", path)
+	case strings.ToLower(arg.Source):
+		v.report(SeverityError, CheckInvalidArgument, "%s has unknown source %q", path, arg.Source)
+	default:
+		v.report(SeverityError, CheckInvalidArgument, "%s has unknown source %q, sources are lowercase", path, arg.Source)
+	}
+}
+
+func (v *validation) checkRule(r *hook.Rules, path string) {
+	switch {
+	case r.And != nil:
+		v.checkGroup(*r.And, path+".and")
+	case r.Or != nil:
+		v.checkGroup(*r.Or, path+".or")
+	case r.Not != nil:
+		v.checkRule((*hook.Rules)(r.Not), path+".not")
+	case r.Match != nil:
+		v.checkMatch(r.Match, path+".match")
+	default:
+		v.report(SeverityError, CheckEmptyGroup, "%s has no rule", path)
+	}
+}
+
+func (v *validation) checkGroup(rules []hook.Rules, path string) {
+	if len(rules) == 0 {
+		v.report(SeverityError, CheckEmptyGroup, "%s has no rules", path)
+	}
+
+	for i := range rules {
+		v.checkRule(&rules[i], fmt.Sprintf("%s[%d]", path, i))
+	}
+}
+
+func (v *validation) checkMatch(m *hook.MatchRule, path string) {
+	v.checkArgument(path+".parameter", m.Parameter)
+
+	switch m.Type {
+	case hook.MatchValue:
+	case hook.MatchRegex:
+		if v.opaque(m.Regex) {
+			return
+		}
+
+		if _, err := regexp.Compile(m.Regex); err != nil {
+			v.report(SeverityError, CheckInvalidRegex, "%s regex %q is invalid: %s", path, m.Regex, err)
+		}
+	case hook.MatchHashSHA1:
+		if m.Secret == "" {
+			v.report(SeverityError, CheckInvalidMatch, "%s has no secret", path)
+		}
+	default:
+		v.report(SeverityError, CheckInvalidMatch, "%s has unknown type %q", path, m.Type)
+	}
+}
@@ -0,0 +1,145 @@
+package validator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/adnanh/webhook/hook"
+)
+
+func match(m hook.MatchRule) *hook.Rules {
+	return &hook.Rules{Match: &m}
+}
+
+func TestValidate(t *testing.T) {
+	header := hook.Argument{Source: hook.SourceHeader, Name: "X-Token"}
+	empty := hook.OrRule{}
+
+	tests := []struct {
+		name  string
+		hooks []hook.Hook
+		want  []string
+	}{
+		{"valid", []hook.Hook{{ID: "a", ExecuteCommand: "/bin/a", TriggerRule: match(hook.MatchRule{Type: hook.MatchValue, Value: "x", Parameter: header})}}, nil},
+		{"empty id", []hook.Hook{{ExecuteCommand: "/bin/a"}}, []string{"0 error empty-id"}},
+		{"slash in id", []hook.Hook{{ID: "a/b", ExecuteCommand: "/bin/a"}}, []string{"0 error unsafe-id"}},
+		{"escaped id", []hook.Hook{{ID: "a b", ExecuteCommand: "/bin/a"}}, []string{"0 warning unsafe-id"}},
+		{"duplicate id", []hook.Hook{{ID: "a", ExecuteCommand: "/bin/a"}, {ID: "b", ExecuteCommand: "/bin/b"}, {ID: "a", ExecuteCommand: "/bin/c"}}, []string{"2 error duplicate-id"}},
+		{"missing command", []hook.Hook{{ID: "a"}}, []string{"0 error missing-command"}},
+		{"arguments", []hook.Hook{{ID: "a", ExecuteCommand: "/bin/a", PassArgumentsToCommand: []hook.Argument{
+			{Source: hook.SourceEntirePayload},
+			{Source: hook.SourcePayload},
+			{Name: "x"},
+			{Source: "cookie", Name: "x"},
+			{Source: "Header", Name: "x"},
+		}}}, []string{"0 error invalid-argument", "0 error invalid-argument", "0 error invalid-argument", "0 error invalid-argument"}},
+		{"rules", []hook.Hook{{ID: "a", ExecuteCommand: "/bin/a", TriggerRule: &hook.Rules{And: &hook.AndRule{
+			*match(hook.MatchRule{Type: hook.MatchRegex, Regex: "(", Parameter: header}),
+			*match(hook.MatchRule{Type: hook.MatchHashSHA1, Parameter: header}),
+			*match(hook.MatchRule{Type: "equals", Parameter: header}),
+			{Or: &empty},
+			{},
+		}}}}, []string{"0 error invalid-regex", "0 error invalid-match", "0 error invalid-match", "0 error empty-group", "0 error empty-group"}},
+		{"opaque regex", []hook.Hook{{ID: "a", ExecuteCommand: "/bin/a", TriggerRule: match(hook.MatchRule{Type: hook.MatchRegex, Regex: "{{ (", Parameter: header})}}, nil},
+	}
+
+	options := Options{Opaque: func(value string) bool { return strings.Contains(value, "{{") }}
+
+	for _, test := range tests {
+		var got []string
+
+		for _, finding := range Validate(test.hooks, options) {
+			got = append(got, fmt.Sprintf("%d %s %s", finding.Position, finding.Severity, finding.Check))
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestValidateMessages(t *testing.T) {
+	hooks := []hook.Hook{
+		{ID: "a", ExecuteCommand: "/bin/a"},
+		{ID: "a", ExecuteCommand: "/bin/a", PassArgumentsToCommand: []hook.Argument{{Source: "Payload", Name: "x"}}},
+	}
+
+	locate := func(position int) string {
+		return fmt.Sprintf("hooks.json[%d]", position+5)
+	}
+
+	findings := Validate(hooks, Options{Locate: locate})
+
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings, want 2", len(findings))
+	}
+
+	if !strings.Contains(findings[0].Message, "hooks.json[5]") {
+		t.Errorf("duplicate id: got %q, want the located position of the first hook", findings[0].Message)
+	}
+
+	if !strings.Contains(findings[1].Message, `"Payload"`) {
+		t.Errorf("source case: got %q", findings[1].Message)
+	}
+}
+
+func TestValidateFilesystem(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validator")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	script := filepath.Join(dir, "script.sh")
+	plain := filepath.Join(dir, "plain.txt")
+
+	if err := ioutil.WriteFile(script, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(plain, []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		hook hook.Hook
+		want []string
+	}{
+		{"executable", hook.Hook{ID: "a", ExecuteCommand: script}, nil},
+		{"relative to the working directory", hook.Hook{ID: "a", ExecuteCommand: "./script.sh", CommandWorkingDirectory: dir}, nil},
+		{"missing command", hook.Hook{ID: "a", ExecuteCommand: filepath.Join(dir, "missing")}, []string{"command"}},
+		{"directory command", hook.Hook{ID: "a", ExecuteCommand: dir}, []string{"command"}},
+		{"not in path", hook.Hook{ID: "a", ExecuteCommand: "hookman-missing-command"}, []string{"command"}},
+		{"missing working directory", hook.Hook{ID: "a", ExecuteCommand: script, CommandWorkingDirectory: filepath.Join(dir, "missing")}, []string{"working-directory"}},
+		{"file working directory", hook.Hook{ID: "a", ExecuteCommand: script, CommandWorkingDirectory: plain}, []string{"working-directory"}},
+	}
+
+	if runtime.GOOS != "windows" {
+		tests = append(tests, struct {
+			name string
+			hook hook.Hook
+			want []string
+		}{"not executable", hook.Hook{ID: "a", ExecuteCommand: plain}, []string{"command"}})
+	}
+
+	for _, test := range tests {
+		var got []string
+
+		for _, finding := range Validate([]hook.Hook{test.hook}, Options{Filesystem: true}) {
+			got = append(got, finding.Check)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
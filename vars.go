@@ -0,0 +1,327 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adnanh/hookman/document"
+	"github.com/adnanh/webhook/hook"
+)
+
+// variablePattern matches the ${VAR} placeholders and the $${ escapes of a literal ${
+var variablePattern = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+var (
+	varsFiles      []string
+	envFile        string
+	substituteVars bool
+)
+
+// substitutionEnabled reports whether the rendered hooks get their ${VAR} placeholders
+// filled, it is off unless asked for since commands often use ${VAR} for the shell
+func substitutionEnabled() bool {
+	return substituteVars || len(varsFiles) > 0 || envFile != ""
+}
+
+// variables holds the values the ${VAR} placeholders are filled from
+type variables struct {
+	sources []map[string]string
+}
+
+// lookup returns the value of the variable from the first source defining it, the process
+// environment being the last one
+func (v *variables) lookup(name string) (string, bool) {
+	for _, source := range v.sources {
+		if value, ok := source[name]; ok {
+			return value, true
+		}
+	}
+
+	return os.LookupEnv(name)
+}
+
+// loadVariables reads the vars files and the .env file, the .env file next to the first
+// hooks file is used when it exists and no other is given
+func loadVariables() (*variables, error) {
+	v := &variables{}
+
+	for _, path := range varsFiles {
+		values, err := readVariablesFile(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		v.sources = append(v.sources, values)
+	}
+
+	path := envFile
+
+	if path == "" && len(sourceFiles) > 0 && sourceFiles[0].path != stdioFile {
+		if candidate := filepath.Join(filepath.Dir(sourceFiles[0].path), ".env"); fileExists(candidate) {
+			path = candidate
+		}
+	}
+
+	if path != "" {
+		values, err := readDotEnv(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		v.sources = append(v.sources, values)
+	}
+
+	return v, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+
+	return err == nil
+}
+
+// readVariablesFile reads a JSON or YAML object of variables, any other file is read as a
+// .env file
+func readVariablesFile(path string) (map[string]string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".json5", ".yaml", ".yml":
+	default:
+		return readDotEnv(path)
+	}
+
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	format, _ := detectFormat("", path)
+
+	if data, err = codecs[format].toJSON(data); err != nil {
+		return nil, fmt.Errorf("could not load vars file %s: %s", path, err)
+	}
+
+	var raw map[string]interface{}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("vars file %s must map variable names to values: %s", path, err)
+	}
+
+	values := make(map[string]string)
+
+	for name, value := range raw {
+		if s, ok := value.(string); ok {
+			values[name] = s
+		} else {
+			encoded, _ := json.Marshal(value)
+			values[name] = string(encoded)
+		}
+	}
+
+	return values, nil
+}
+
+// readDotEnv reads NAME=value lines, blank lines, comments and export prefixes are skipped
+// and values may be quoted
+func readDotEnv(path string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		text = strings.TrimSpace(strings.TrimPrefix(text, "export "))
+		split := strings.SplitN(text, "=", 2)
+
+		if len(split) != 2 || strings.TrimSpace(split[0]) == "" {
+			return nil, fmt.Errorf("%s:%d: expected NAME=value", path, line)
+		}
+
+		name, value := strings.TrimSpace(split[0]), strings.TrimSpace(split[1])
+
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		}
+
+		values[name] = value
+	}
+
+	return values, scanner.Err()
+}
+
+// substitute fills the placeholders of the string, the names of the undefined variables
+// are added to undefined
+func (v *variables) substitute(s string, undefined map[string]bool) string {
+	return variablePattern.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+
+		name := match[2 : len(match)-1]
+		value, ok := v.lookup(name)
+
+		if !ok {
+			undefined[name] = true
+		}
+
+		return value
+	})
+}
+
+// substituteJSON fills the placeholders of every string in the JSON value, objects keep
+// the order of their keys
+func (v *variables) substituteJSON(raw json.RawMessage, undefined map[string]bool) (json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(raw)
+
+	if len(trimmed) == 0 {
+		return raw, nil
+	}
+
+	switch trimmed[0] {
+	case '"':
+		var s string
+
+		if err := json.Unmarshal(trimmed, &s); err != nil {
+			return nil, err
+		}
+
+		if !strings.Contains(s, "${") {
+			return raw, nil
+		}
+
+		return encodeJSON(v.substitute(s, undefined), "", "")
+
+	case '{':
+		o := document.New()
+
+		if err := json.Unmarshal(trimmed, o); err != nil {
+			return nil, err
+		}
+
+		if err := v.substituteObject(o, undefined); err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(o)
+
+	case '[':
+		var elements []json.RawMessage
+
+		if err := json.Unmarshal(trimmed, &elements); err != nil {
+			return nil, err
+		}
+
+		for i := range elements {
+			substituted, err := v.substituteJSON(elements[i], undefined)
+
+			if err != nil {
+				return nil, err
+			}
+
+			elements[i] = substituted
+		}
+
+		return json.Marshal(elements)
+	}
+
+	return raw, nil
+}
+
+func (v *variables) substituteObject(o *document.Object, undefined map[string]bool) error {
+	for _, key := range o.Keys {
+		substituted, err := v.substituteJSON(o.Values[key], undefined)
+
+		if err != nil {
+			return err
+		}
+
+		o.Values[key] = substituted
+	}
+
+	return nil
+}
+
+// substituteDocuments fills the placeholders of the hook documents, it fails listing every
+// undefined variable
+func (v *variables) substituteDocuments(documents []*document.Object) error {
+	undefined := make(map[string]bool)
+
+	for _, d := range documents {
+		if err := v.substituteObject(d, undefined); err != nil {
+			return err
+		}
+	}
+
+	if len(undefined) > 0 {
+		names := make([]string, 0, len(undefined))
+
+		for name := range undefined {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		return fmt.Errorf("undefined variable(s): %s", strings.Join(names, ", "))
+	}
+
+	return nil
+}
+
+// resolveHooks replaces the loaded hooks with their resolved versions
+func resolveHooks() error {
+	v, err := loadVariables()
+
+	if err != nil {
+		return err
+	}
+
+	documents := make([]*document.Object, len(hooks))
+
+	for i := range hooks {
+		if documents[i], err = mergeHookDocument(hookSources[i].document, &hooks[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := v.substituteDocuments(documents); err != nil {
+		return err
+	}
+
+	for i := range hooks {
+		var resolved hook.Hook
+
+		if err := documents[i].Decode(&resolved); err != nil {
+			return err
+		}
+
+		hooks[i] = resolved
+	}
+
+	return nil
+}